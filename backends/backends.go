@@ -2,9 +2,14 @@ package backends
 
 import (
 	"errors"
+	"fmt"
+	"io"
 	"net/url"
+	"os"
+	"path/filepath"
 
 	"github.com/toxinu/katago/client"
+	"github.com/toxinu/katago/config"
 )
 
 // Manga represents a manga
@@ -31,20 +36,206 @@ type Page struct {
 // Backend interface represents a service to download manga
 type Backend interface {
 	Name() string
-	Search(string) ([]*Manga, error)
+	Search(*Query) ([]*Manga, error)
 	Chapters(*Manga) ([]*Chapter, error)
 	Pages(*Chapter) ([]*Page, error)
 	PageImageURL(*Page) (*url.URL, error)
 }
 
+// Credentials represents a backend account
+type Credentials struct {
+	Username string
+	Password string
+}
+
+// Authenticator is implemented by backends requiring a login, Login should
+// return early when the session persisted in the cookie jar is still valid
+type Authenticator interface {
+	Login(Credentials) error
+}
+
+// Expirer is implemented by backends caching what image URLs are built
+// from, Expire drops what was cached for a page whose image failed so the
+// next PageImageURL call looks it up again
+type Expirer interface {
+	Expire(*Page)
+}
+
 // Backends is declared backends
 var Backends map[string]Backend
 
-// Initialize initialize every backends
-func Initialize(c *client.Client) {
-	Backends = map[string]Backend{
-		"mangafox": &MangaFox{Client: c},
+// Clients is declared backends clients, keyed like Backends
+var Clients map[string]*client.Client
+
+// LoadErrors lists why definitions, scripts and plugins, or backend cookie
+// jars, were skipped by the last Initialize
+var LoadErrors []error
+
+// Close releases what declared backends hold, like plugin processes
+func Close() {
+	for _, b := range Backends {
+		if closer, ok := b.(io.Closer); ok {
+			closer.Close()
+		}
+	}
+}
+
+// Initialize initialize every backends, definitions, scripts and plugins
+// found in configured directories override compiled backends sharing their
+// slug, the ones failing to load are skipped and listed in LoadErrors
+func Initialize(c *client.Client, cfg *config.Config) error {
+	Close()
+
+	Backends = map[string]Backend{}
+	Clients = map[string]*client.Client{}
+	LoadErrors = nil
+
+	mangafoxClient, err := BackendClient(c, cfg, "mangafox", MangaFoxLimit)
+	if err != nil {
+		return err
+	}
+	register("mangafox", &MangaFox{Client: mangafoxClient}, mangafoxClient)
+
+	mangadexClient, err := BackendClient(c, cfg, "mangadex", MangaDexLimit)
+	if err != nil {
+		return err
+	}
+	register("mangadex", &MangaDex{
+		Client:    mangadexClient,
+		Languages: cfg.Backend("mangadex").Languages,
+		DataSaver: cfg.Backend("mangadex").DataSaver,
+	}, mangadexClient)
+
+	library, err := filepath.Abs(cfg.LibraryDir())
+	if err != nil {
+		return err
+	}
+	localClient, err := BackendClient(c, cfg, "local", client.Limit{})
+	if err != nil {
+		return err
+	}
+	localClient = localClient.WithLocalFiles()
+	register("local", &Local{Dir: library}, localClient)
+
+	definitions, errs := LoadDefinitions(cfg.DefinitionsDir())
+	LoadErrors = append(LoadErrors, errs...)
+	for _, definition := range definitions {
+		definitionClient, err := BackendClient(c, cfg, definition.Slug, definition.clientLimit())
+		if err != nil {
+			LoadErrors = append(LoadErrors, fmt.Errorf("definition %s: %w", definition.Slug, err))
+			continue
+		}
+		register(definition.Slug, &Scraper{Definition: definition, Client: definitionClient}, definitionClient)
+	}
+
+	scripts, errs := LoadScripts(cfg.ScriptsDir())
+	LoadErrors = append(LoadErrors, errs...)
+	for _, script := range scripts {
+		script.Client, err = BackendClient(c, cfg, script.Slug, script.Limit())
+		if err != nil {
+			LoadErrors = append(LoadErrors, fmt.Errorf("script %s: %w", script.Slug, err))
+			continue
+		}
+		register(script.Slug, script, script.Client)
+	}
+
+	plugins, err := LoadPlugins(cfg.PluginsDir())
+	if err != nil {
+		LoadErrors = append(LoadErrors, err)
+	}
+	for _, plugin := range plugins {
+		pluginClient, err := BackendClient(c, cfg, plugin.Slug, client.Limit{})
+		if err != nil {
+			LoadErrors = append(LoadErrors, fmt.Errorf("plugin %s: %w", plugin.Slug, err))
+			continue
+		}
+		register(plugin.Slug, plugin, pluginClient)
+	}
+
+	return nil
+}
+
+func register(slug string, b Backend, c *client.Client) {
+	Backends[slug] = b
+	Clients[slug] = c
+}
+
+// BackendClient returns a copy of Client configured for given backend,
+// configuration overrides backend default limit
+func BackendClient(c *client.Client, cfg *config.Config, slug string, limit client.Limit) (*client.Client, error) {
+	backendConfig := cfg.Backend(slug)
+	if backendConfig.RateLimit > 0 {
+		limit.Rate = backendConfig.RateLimit
+	}
+	if backendConfig.RateBurst > 0 {
+		limit.Burst = backendConfig.RateBurst
+	}
+	if backendConfig.MaxConnections > 0 {
+		limit.MaxConnections = backendConfig.MaxConnections
+	}
+	c = c.WithLimit(limit)
+
+	var err error
+	if len(backendConfig.Proxy) > 0 {
+		options := c.Transport
+		options.Proxy = backendConfig.Proxy
+		c, err = c.WithTransport(options)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// A broken cookie jar only costs the backend its session, it is listed
+	// in LoadErrors and the backend is used without cookies
+	jar, err := cookieJar(cfg, slug)
+	if err != nil {
+		LoadErrors = append(LoadErrors, fmt.Errorf("%s cookies, backend used without: %w", slug, err))
+		return c, nil
+	}
+	return c.WithCookieJar(jar), nil
+}
+
+// cookieJar returns given backend cookie jar, with its configured
+// cookies.txt imported
+func cookieJar(cfg *config.Config, slug string) (*client.CookieJar, error) {
+	jar, err := client.OpenCookieJar(cfg.CookieJarPath(slug))
+	if err != nil {
+		return nil, err
+	}
+
+	cookiesFile := cfg.Backend(slug).CookiesFile
+	if len(cookiesFile) == 0 {
+		return jar, nil
+	}
+
+	file, err := os.Open(cookiesFile)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	err = jar.ImportNetscape(file)
+	if err != nil {
+		return nil, err
 	}
+	return jar, nil
+}
+
+// Login logs in given backend if it requires it and credentials are configured
+func Login(b Backend, cfg *config.Config, slug string) error {
+	if !Has(b, CapabilityLogin) {
+		return nil
+	}
+
+	backendConfig := cfg.Backend(slug)
+	if len(backendConfig.Username) == 0 {
+		return nil
+	}
+
+	return b.(Authenticator).Login(Credentials{
+		Username: backendConfig.Username,
+		Password: backendConfig.Password,
+	})
 }
 
 // Get returns a Backend
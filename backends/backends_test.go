@@ -0,0 +1,131 @@
+package backends_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/toxinu/katago/backends"
+	"github.com/toxinu/katago/backends/backendtest"
+	"github.com/toxinu/katago/client"
+	"github.com/toxinu/katago/config"
+)
+
+// replay starts a server replaying the cassette recorded at given path,
+// closed when the test ends
+func replay(t *testing.T, path string) *backendtest.Server {
+	server, err := backendtest.LoadServer(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(server.Close)
+	return server
+}
+
+// expectWalk checks what a backend returned while browsing a cassette
+func expectWalk(t *testing.T, walk *backendtest.Walk, results int, chapter string, chapterURL string, pages int, imageURL string) {
+	t.Helper()
+
+	if len(walk.Results) != results {
+		t.Fatalf("got %d results, want %d", len(walk.Results), results)
+	}
+	if walk.Results[0].Name != "Berserk" || walk.Results[0].Author != "Miura Kentarou" {
+		t.Errorf("got first result %q by %q", walk.Results[0].Name, walk.Results[0].Author)
+	}
+	if walk.Chapters[0].Name != chapter || walk.Chapters[0].URL.String() != chapterURL {
+		t.Errorf("got first chapter %q at %s, want %q at %s", walk.Chapters[0].Name, walk.Chapters[0].URL, chapter, chapterURL)
+	}
+	if len(walk.Pages) != pages {
+		t.Errorf("got %d pages, want %d", len(walk.Pages), pages)
+	}
+	if walk.ImageURL.String() != imageURL {
+		t.Errorf("got image %s, want %s", walk.ImageURL, imageURL)
+	}
+}
+
+func TestBackendClientSkipsBrokenCookies(t *testing.T) {
+	dir, err := ioutil.TempDir("", "katago-config-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	cfg := config.New()
+	cfg.Cookies = dir
+	cfg.Backends["mangafox"] = &config.Backend{CookiesFile: filepath.Join(dir, "missing.txt")}
+	backends.LoadErrors = nil
+
+	c, err := backends.BackendClient(client.NewClient(), cfg, "mangafox", backends.MangaFoxLimit)
+	if err != nil {
+		t.Fatalf("unreadable cookies file failed the backend: %s", err)
+	}
+	if c == nil || c.HTTPClient.Jar != nil {
+		t.Error("backend uses a jar whose cookies file failed to load")
+	}
+	if len(backends.LoadErrors) != 1 || !strings.Contains(backends.LoadErrors[0].Error(), "mangafox cookies") {
+		t.Errorf("got load errors %v, want the mangafox cookies error", backends.LoadErrors)
+	}
+}
+
+// expectLink checks a resolved Link points to given manga, chapter and page
+func expectLink(t *testing.T, link *backends.Link, manga string, chapter string, page string) {
+	t.Helper()
+
+	var chapterName, pageURL string
+	if link.Chapter != nil {
+		chapterName = link.Chapter.Name
+	}
+	if link.Page != nil {
+		pageURL = link.Page.URL.String()
+	}
+	if link.Manga.Name != manga || chapterName != chapter || pageURL != page {
+		t.Errorf("got link to %q, %q, %q, want %q, %q, %q", link.Manga.Name, chapterName, pageURL, manga, chapter, page)
+	}
+}
+
+func mustParse(t *testing.T, raw string) *url.URL {
+	t.Helper()
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u
+}
+
+func TestResolveURL(t *testing.T) {
+	server := replay(t, "testdata/mangafox.json")
+	dir := localLibrary(t)
+	available := map[string]backends.Backend{
+		"local":    &backends.Local{Dir: dir},
+		"mangafox": &backends.MangaFox{Client: server.Client()},
+	}
+
+	tests := []struct {
+		link    string
+		slug    string
+		chapter string
+	}{
+		{"https://www.mangafox.la/manga/berserk/v01/c001/1.html", "mangafox", "Berserk 1"},
+		{(&url.URL{Scheme: "file", Path: filepath.ToSlash(filepath.Join(dir, "Berserk", "Chapter 1"))}).String(), "local", "Chapter 1"},
+	}
+	for _, test := range tests {
+		slug, link, err := backends.ResolveURL(available, mustParse(t, test.link))
+		if err != nil {
+			t.Errorf("%s: %s", test.link, err)
+			continue
+		}
+		if slug != test.slug || link.Chapter == nil || link.Chapter.Name != test.chapter {
+			t.Errorf("%s: got %q, %+v, want %q, %q", test.link, slug, link, test.slug, test.chapter)
+		}
+	}
+
+	_, _, err := backends.ResolveURL(available, mustParse(t, "https://example.com/manga/berserk/"))
+	if !errors.Is(err, backends.ErrUnknownURL) {
+		t.Errorf("got %v, want ErrUnknownURL", err)
+	}
+}
@@ -0,0 +1,109 @@
+package backendtest
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+
+	"github.com/toxinu/katago/backends"
+	"github.com/toxinu/katago/client"
+	"github.com/toxinu/katago/config"
+)
+
+// Walk is what a backend returned while browsing from a search to an image
+type Walk struct {
+	Results  []*backends.Manga
+	Chapters []*backends.Chapter
+	Pages    []*backends.Page
+	ImageURL *url.URL
+}
+
+// Browse searches given term, then walks first result, its first chapter
+// and the chapter first page
+func Browse(b backends.Backend, term string) (*Walk, error) {
+	var (
+		err  error
+		walk = &Walk{}
+	)
+
+	walk.Results, err = b.Search(backends.NewQuery(term))
+	if err != nil {
+		return walk, fmt.Errorf("search: %w", err)
+	}
+	if len(walk.Results) == 0 {
+		return walk, errors.New("search: no result")
+	}
+
+	walk.Chapters, err = b.Chapters(walk.Results[0])
+	if err != nil {
+		return walk, fmt.Errorf("chapters: %w", err)
+	}
+	if len(walk.Chapters) == 0 {
+		return walk, errors.New("chapters: no chapter")
+	}
+
+	walk.Pages, err = b.Pages(walk.Chapters[0])
+	if err != nil {
+		return walk, fmt.Errorf("pages: %w", err)
+	}
+	if len(walk.Pages) == 0 {
+		return walk, errors.New("pages: no page")
+	}
+
+	walk.ImageURL, err = b.PageImageURL(walk.Pages[0])
+	if err != nil {
+		return walk, fmt.Errorf("page image url: %w", err)
+	}
+
+	return walk, nil
+}
+
+// Record browses given backend on the live site like Browse, fetches the
+// page image and saves every interaction in a cassette at given path,
+// cookies go to a throwaway jar so the user ones are neither sent nor
+// updated
+func Record(slug string, term string, path string) (*Walk, error) {
+	cfg, err := config.Load(config.Path())
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.Cookies, err = ioutil.TempDir("", "katago-record-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(cfg.Cookies)
+	for _, backendConfig := range cfg.Backends {
+		if backendConfig != nil {
+			backendConfig.CookiesFile = ""
+		}
+	}
+
+	cassette := client.NewCassette()
+	c := client.NewClient().WithRecorder(&client.Recorder{Cassette: cassette, Mode: client.ModeRecord})
+
+	err = backends.Initialize(c, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := backends.Get(slug)
+	if err != nil {
+		return nil, err
+	}
+
+	walk, err := Browse(b, term)
+	if err != nil {
+		return walk, err
+	}
+
+	resp, err := c.For(client.RequestImage).Get(walk.ImageURL, []int{200})
+	if err != nil {
+		return walk, fmt.Errorf("page image: %w", err)
+	}
+	resp.Body.Close()
+
+	return walk, cassette.Save(path)
+}
@@ -0,0 +1,263 @@
+package backendtest
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"strings"
+
+	"github.com/toxinu/katago/backends"
+	"github.com/toxinu/katago/client"
+)
+
+// DefaultChapterNumber reads chapter number from chapter name or URL
+func DefaultChapterNumber(chapter *backends.Chapter) (float64, bool) {
+	return backends.ChapterNumber(chapter)
+}
+
+// Suite checks a Backend honours the Backend contract against a fixture
+// server, every backend is expected to pass it
+type Suite struct {
+	Backend backends.Backend
+	// Term is searched to find the manga to walk
+	Term string
+	// Client fetches image URLs, usually Server.Client()
+	Client *client.Client
+	// ChapterNumber reads chapter numbers to check their order, chapters
+	// it cannot read fail the suite. DefaultChapterNumber is used when nil
+	ChapterNumber func(*backends.Chapter) (float64, bool)
+	// Images is the number of pages whose image is fetched, all when zero
+	Images int
+}
+
+// ConformanceError lists every contract violation found by a Suite
+type ConformanceError struct {
+	Backend  string
+	Failures []string
+}
+
+func (e *ConformanceError) Error() string {
+	return fmt.Sprintf("%s does not conform to Backend:\n - %s", e.Backend, strings.Join(e.Failures, "\n - "))
+}
+
+type suiteRun struct {
+	*Suite
+	failures []string
+}
+
+func (r *suiteRun) fail(format string, args ...interface{}) {
+	r.failures = append(r.failures, fmt.Sprintf(format, args...))
+}
+
+// check runs a step and turns its panics into failures
+func (r *suiteRun) check(step string, f func()) (ok bool) {
+	count := len(r.failures)
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			r.fail("%s: panic: %v", step, recovered)
+			ok = false
+		}
+	}()
+	f()
+	return len(r.failures) == count
+}
+
+// Run runs every check and returns a ConformanceError on violations
+func (s *Suite) Run() error {
+	r := &suiteRun{Suite: s}
+	if r.ChapterNumber == nil {
+		r.ChapterNumber = DefaultChapterNumber
+	}
+
+	var (
+		results  []*backends.Manga
+		chapters []*backends.Chapter
+		pages    []*backends.Page
+	)
+
+	r.check("name", func() {
+		if len(strings.TrimSpace(s.Backend.Name())) == 0 {
+			r.fail("name: empty")
+		}
+	})
+
+	if r.check("search", func() { results = r.search() }) &&
+		r.check("chapters", func() { chapters = r.chapters(results[0]) }) &&
+		r.check("pages", func() { pages = r.pages(chapters[0]) }) {
+		r.check("page image url", func() { r.images(pages) })
+	}
+
+	r.check("errors", r.errors)
+
+	if len(r.failures) > 0 {
+		return &ConformanceError{Backend: s.Backend.Name(), Failures: r.failures}
+	}
+	return nil
+}
+
+func (r *suiteRun) search() []*backends.Manga {
+	results, err := r.Backend.Search(backends.NewQuery(r.Term))
+	if err != nil {
+		r.fail("search: %s", err)
+		return nil
+	}
+	if len(results) == 0 {
+		r.fail("search: no result for %q", r.Term)
+		return nil
+	}
+
+	for index, manga := range results {
+		if manga == nil {
+			r.fail("search: result %d is nil", index)
+			continue
+		}
+		if len(strings.TrimSpace(manga.Name)) == 0 {
+			r.fail("search: result %d has no name", index)
+		}
+		if !absolute(manga.URL) {
+			r.fail("search: result %d URL %v is not absolute", index, manga.URL)
+		}
+	}
+	return results
+}
+
+func (r *suiteRun) chapters(manga *backends.Manga) []*backends.Chapter {
+	chapters, err := r.Backend.Chapters(manga)
+	if err != nil {
+		r.fail("chapters: %s", err)
+		return nil
+	}
+	if len(chapters) == 0 {
+		r.fail("chapters: no chapter for %s", manga.Name)
+		return nil
+	}
+
+	seen := map[string]bool{}
+	previous, previousName := -1.0, ""
+	for index, chapter := range chapters {
+		if chapter == nil {
+			r.fail("chapters: chapter %d is nil", index)
+			continue
+		}
+		if !absolute(chapter.URL) {
+			r.fail("chapters: chapter %d URL %v is not absolute", index, chapter.URL)
+			continue
+		}
+		if seen[chapter.URL.String()] {
+			r.fail("chapters: chapter %d URL %s is duplicated", index, chapter.URL)
+		}
+		seen[chapter.URL.String()] = true
+
+		number, ok := r.ChapterNumber(chapter)
+		if !ok {
+			r.fail("chapters: cannot read number of %q to check order", chapter.Name)
+			continue
+		}
+		if number < previous {
+			r.fail("chapters: not ordered oldest first, %q comes after %q", chapter.Name, previousName)
+		}
+		previous, previousName = number, chapter.Name
+	}
+	return chapters
+}
+
+func (r *suiteRun) pages(chapter *backends.Chapter) []*backends.Page {
+	pages, err := r.Backend.Pages(chapter)
+	if err != nil {
+		r.fail("pages: %s", err)
+		return nil
+	}
+	if len(pages) == 0 {
+		r.fail("pages: no page for %s", chapter.Name)
+		return nil
+	}
+
+	seen := map[string]bool{}
+	for index, page := range pages {
+		if page == nil || !absolute(page.URL) {
+			r.fail("pages: page %d has no absolute URL", index)
+			continue
+		}
+		if seen[page.URL.String()] {
+			r.fail("pages: page %d URL %s is duplicated", index, page.URL)
+		}
+		seen[page.URL.String()] = true
+	}
+	return pages
+}
+
+func (r *suiteRun) images(pages []*backends.Page) {
+	if r.Images > 0 && r.Images < len(pages) {
+		pages = pages[:r.Images]
+	}
+
+	for index, page := range pages {
+		if page == nil || page.URL == nil {
+			continue
+		}
+
+		imageURL, err := r.Backend.PageImageURL(page)
+		if err != nil {
+			r.fail("page image url: page %d: %s", index, err)
+			continue
+		}
+		if !absolute(imageURL) {
+			r.fail("page image url: page %d image URL %v is not absolute", index, imageURL)
+			continue
+		}
+
+		if r.Client == nil {
+			continue
+		}
+
+		resp, err := r.Client.For(client.RequestImage).Get(imageURL, []int{200})
+		if err != nil {
+			r.fail("page image url: page %d image does not resolve: %s", index, err)
+			continue
+		}
+		data, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil || len(data) == 0 {
+			r.fail("page image url: page %d image %s is empty", index, imageURL)
+		}
+		contentType := resp.Header.Get("Content-Type")
+		if len(contentType) > 0 && !strings.HasPrefix(contentType, "image/") {
+			r.fail("page image url: page %d image %s has content type %s", index, imageURL, contentType)
+		}
+	}
+}
+
+// errors checks backend methods fail cleanly on missing resources
+func (r *suiteRun) errors() {
+	missing := &url.URL{Scheme: "http", Host: "katago.invalid", Path: "/missing"}
+
+	chapters, err := r.Backend.Chapters(&backends.Manga{Name: "missing", URL: missing})
+	r.expectError("chapters", err, len(chapters))
+
+	pages, err := r.Backend.Pages(&backends.Chapter{Name: "missing", URL: missing})
+	r.expectError("pages", err, len(pages))
+
+	imageURL, err := r.Backend.PageImageURL(&backends.Page{URL: missing})
+	if imageURL != nil {
+		r.expectError("page image url", err, 1)
+	} else {
+		r.expectError("page image url", err, 0)
+	}
+}
+
+func (r *suiteRun) expectError(step string, err error, results int) {
+	switch {
+	case err == nil:
+		r.fail("errors: %s of a missing resource returned no error", step)
+	case results > 0:
+		r.fail("errors: %s of a missing resource returned results along its error", step)
+	case !errors.Is(err, client.ErrNotFound):
+		r.fail("errors: %s of a missing resource returned %q which does not match client.ErrNotFound", step, err)
+	}
+}
+
+// absolute reports whether u has a scheme and a host, or is a file URL
+func absolute(u *url.URL) bool {
+	return u != nil && u.IsAbs() && (len(u.Host) > 0 || (u.Scheme == "file" && len(u.Path) > 0))
+}
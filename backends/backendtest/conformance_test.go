@@ -0,0 +1,125 @@
+package backendtest_test
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/toxinu/katago/backends"
+	"github.com/toxinu/katago/backends/backendtest"
+	"github.com/toxinu/katago/client"
+)
+
+// broken is a Memory backend whose answers are altered
+type broken struct {
+	*backendtest.Memory
+	results  func([]*backends.Manga) []*backends.Manga
+	chapters func([]*backends.Chapter) []*backends.Chapter
+	pages    func([]*backends.Page) []*backends.Page
+}
+
+func (b *broken) Search(query *backends.Query) ([]*backends.Manga, error) {
+	results, err := b.Memory.Search(query)
+	if err != nil || b.results == nil {
+		return results, err
+	}
+	return b.results(append([]*backends.Manga(nil), results...)), nil
+}
+
+func (b *broken) Chapters(manga *backends.Manga) ([]*backends.Chapter, error) {
+	chapters, err := b.Memory.Chapters(manga)
+	if err != nil || b.chapters == nil {
+		return chapters, err
+	}
+	return b.chapters(append([]*backends.Chapter(nil), chapters...)), nil
+}
+
+func (b *broken) Pages(chapter *backends.Chapter) ([]*backends.Page, error) {
+	pages, err := b.Memory.Pages(chapter)
+	if err != nil || b.pages == nil {
+		return pages, err
+	}
+	return b.pages(append([]*backends.Page(nil), pages...)), nil
+}
+
+func TestSuiteCatchesBrokenBackends(t *testing.T) {
+	images := backendtest.NewImageServer()
+	defer images.Close()
+	c := client.NewClient()
+	c.Limiter = nil
+
+	tests := []struct {
+		name    string
+		backend *broken
+		failure string
+	}{
+		{
+			name:    "conforming",
+			backend: &broken{},
+		},
+		{
+			name: "reversed chapters",
+			backend: &broken{chapters: func(chapters []*backends.Chapter) []*backends.Chapter {
+				for i, j := 0, len(chapters)-1; i < j; i, j = i+1, j-1 {
+					chapters[i], chapters[j] = chapters[j], chapters[i]
+				}
+				return chapters
+			}},
+			failure: "chapters: not ordered oldest first",
+		},
+		{
+			name: "unnumbered chapters",
+			backend: &broken{chapters: func(chapters []*backends.Chapter) []*backends.Chapter {
+				unnumbered := *chapters[0]
+				unnumbered.Name = "Extra"
+				unnumbered.URL = &url.URL{Scheme: "memory", Host: "katago", Path: "/manga/berserk/extra"}
+				return append(chapters, &unnumbered)
+			}},
+			failure: `chapters: cannot read number of "Extra"`,
+		},
+		{
+			name: "duplicate pages",
+			backend: &broken{pages: func(pages []*backends.Page) []*backends.Page {
+				return append(pages, pages[0])
+			}},
+			failure: "pages: page 3 URL memory://katago/manga/berserk/c001/1 is duplicated",
+		},
+		{
+			name: "empty search URLs",
+			backend: &broken{results: func(results []*backends.Manga) []*backends.Manga {
+				empty := *results[0]
+				empty.URL = &url.URL{}
+				return []*backends.Manga{&empty}
+			}},
+			failure: "search: result 0 URL  is not absolute",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.backend.Memory = backendtest.NewMemory(images)
+			test.backend.AddManga("Berserk", 3, 3)
+
+			suite := &backendtest.Suite{Backend: test.backend, Term: "berserk", Client: c}
+			err := suite.Run()
+			if len(test.failure) == 0 {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			var conformance *backendtest.ConformanceError
+			if !errors.As(err, &conformance) {
+				t.Fatalf("expected a ConformanceError, got %v", err)
+			}
+			for _, failure := range conformance.Failures {
+				if strings.HasPrefix(failure, test.failure) {
+					return
+				}
+			}
+			t.Fatalf("expected a %q failure, got %v", test.failure, err)
+		})
+	}
+}
@@ -0,0 +1,155 @@
+package backendtest
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Behavior tells how an ImageServer answers requests
+type Behavior struct {
+	// Delay is waited before answering
+	Delay time.Duration
+	// Failures is the number of requests answered with FailureStatus
+	// before succeeding
+	Failures int
+	// FailureStatus defaults to 503
+	FailureStatus int
+	// Truncate announces a longer body than the one sent
+	Truncate bool
+	// ContentType overrides "image/png"
+	ContentType string
+	// Redirect answers with a redirection to the actual image
+	Redirect bool
+}
+
+// ImageServer is a fake image server simulating misbehaving sites
+type ImageServer struct {
+	*httptest.Server
+	// Default is the behavior of paths without their own
+	Default Behavior
+
+	mutex     sync.Mutex
+	behaviors map[string]Behavior
+	requests  map[string]int
+	inFlight  int
+	maxFlight int
+}
+
+// redirectedPrefix prefixes paths of images served after a redirection
+const redirectedPrefix = "/redirected"
+
+// NewImageServer starts an ImageServer
+func NewImageServer() *ImageServer {
+	s := &ImageServer{
+		behaviors: map[string]Behavior{},
+		requests:  map[string]int{},
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+// SetBehavior sets given path behavior
+func (s *ImageServer) SetBehavior(path string, behavior Behavior) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.behaviors[path] = behavior
+}
+
+// Requests returns how many times given path was requested
+func (s *ImageServer) Requests(path string) int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.requests[path]
+}
+
+// MaxInFlight returns the highest number of requests served concurrently
+func (s *ImageServer) MaxInFlight() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.maxFlight
+}
+
+// Image returns the image served for given path
+func Image(path string) []byte {
+	img := image.NewGray(image.Rect(0, 0, 1, 1))
+	img.SetGray(0, 0, color.Gray{Y: uint8(len(path))})
+
+	buffer := new(bytes.Buffer)
+	png.Encode(buffer, img)
+	return buffer.Bytes()
+}
+
+// begin records a request and returns its path behavior and count
+func (s *ImageServer) begin(path string) (Behavior, int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.inFlight++
+	if s.inFlight > s.maxFlight {
+		s.maxFlight = s.inFlight
+	}
+	s.requests[path]++
+
+	behavior, ok := s.behaviors[path]
+	if !ok {
+		behavior = s.Default
+	}
+	return behavior, s.requests[path]
+}
+
+func (s *ImageServer) end() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.inFlight--
+}
+
+func (s *ImageServer) serve(w http.ResponseWriter, r *http.Request) {
+	redirected := strings.HasPrefix(r.URL.Path, redirectedPrefix)
+	path := strings.TrimPrefix(r.URL.Path, redirectedPrefix)
+
+	behavior, count := s.begin(path)
+	defer s.end()
+
+	if behavior.Delay > 0 {
+		select {
+		case <-time.After(behavior.Delay):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	if count <= behavior.Failures {
+		status := behavior.FailureStatus
+		if status == 0 {
+			status = http.StatusServiceUnavailable
+		}
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+
+	if behavior.Redirect && !redirected {
+		http.Redirect(w, r, redirectedPrefix+path, http.StatusFound)
+		return
+	}
+
+	contentType := behavior.ContentType
+	if len(contentType) == 0 {
+		contentType = "image/png"
+	}
+
+	data := Image(path)
+	w.Header().Set("Content-Type", contentType)
+	if behavior.Truncate {
+		w.Header().Set("Content-Length", strconv.Itoa(len(data)*2))
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
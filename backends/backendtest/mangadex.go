@@ -0,0 +1,492 @@
+package backendtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/toxinu/katago/client"
+)
+
+// mangaDexServerEpoch is when the first chapter added to a MangaDexServer is
+// readable
+var mangaDexServerEpoch = time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// MangaDexServer is a stand-in for MangaDex API whose images are served by
+// an ImageServer
+type MangaDexServer struct {
+	*httptest.Server
+	Images *ImageServer
+	// PageSize caps the number of feed entries per page, zero keeps the
+	// limit asked for
+	PageSize int
+
+	mutex    sync.Mutex
+	mangas   []*mangaDexServerManga
+	chapters map[string]*mangaDexServerChapter
+	requests map[string]int
+}
+
+type mangaDexServerManga struct {
+	id       string
+	title    string
+	author   string
+	genres   []string
+	status   string
+	chapters []*mangaDexServerChapter
+}
+
+type mangaDexServerChapter struct {
+	id       string
+	manga    *mangaDexServerManga
+	volume   string
+	number   string
+	language string
+	pages    int
+	// readableAt is when the chapter was added, a minute after the previous
+	// one
+	readableAt time.Time
+}
+
+// NewMangaDexServer starts a MangaDexServer
+func NewMangaDexServer(images *ImageServer) *MangaDexServer {
+	s := &MangaDexServer{
+		Images:   images,
+		chapters: map[string]*mangaDexServerChapter{},
+		requests: map[string]int{},
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+// Client returns a Client whose requests are all routed to the
+// MangaDexServer, but image requests
+func (s *MangaDexServer) Client() *client.Client {
+	target, _ := url.Parse(s.URL)
+	images, _ := url.Parse(s.Images.URL)
+
+	c := client.NewClient()
+	c.Limiter = nil
+	c.Retry.MaxAttempts = 1
+	c.HTTPClient = &http.Client{Transport: &routeTransport{target: target, transport: s.Server.Client().Transport, keep: images.Host}}
+	return c
+}
+
+// AddManga adds a manga translated in given languages, having given number
+// of chapters and pages per chapter, and returns its ID
+func (s *MangaDexServer) AddManga(title string, author string, chapterCount int, pageCount int, languages ...string) string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	manga := &mangaDexServerManga{
+		id:     fmt.Sprintf("manga-%d", len(s.mangas)+1),
+		title:  title,
+		author: author,
+	}
+	for c := 1; c <= chapterCount; c++ {
+		for _, language := range languages {
+			chapter := &mangaDexServerChapter{
+				id:       fmt.Sprintf("%s-%s-%d", manga.id, language, c),
+				manga:    manga,
+				number:   strconv.Itoa(c),
+				language: language,
+				pages:    pageCount,
+
+				readableAt: mangaDexServerEpoch.Add(time.Duration(len(s.chapters)) * time.Minute),
+			}
+			manga.chapters = append(manga.chapters, chapter)
+			s.chapters[chapter.id] = chapter
+		}
+	}
+	s.mangas = append(s.mangas, manga)
+	return manga.id
+}
+
+// SetGenres sets given manga genres
+func (s *MangaDexServer) SetGenres(id string, genres ...string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if manga := s.manga(id); manga != nil {
+		manga.genres = genres
+	}
+}
+
+// SetVolumes groups given manga chapters in volumes of given number of
+// chapters, numbered from 1 in every volume
+func (s *MangaDexServer) SetVolumes(id string, chaptersPerVolume int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	manga := s.manga(id)
+	if manga == nil {
+		return
+	}
+	for _, chapter := range manga.chapters {
+		number, _ := strconv.Atoi(chapter.number)
+		chapter.volume = strconv.Itoa((number-1)/chaptersPerVolume + 1)
+		chapter.number = strconv.Itoa((number-1)%chaptersPerVolume + 1)
+	}
+}
+
+// SetStatus sets given manga publication status
+func (s *MangaDexServer) SetStatus(id string, status string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if manga := s.manga(id); manga != nil {
+		manga.status = status
+	}
+}
+
+func (s *MangaDexServer) manga(id string) *mangaDexServerManga {
+	for _, manga := range s.mangas {
+		if manga.id == id {
+			return manga
+		}
+	}
+	return nil
+}
+
+// Requests returns how many times given endpoint ("search", "manga",
+// "chapter", "latest", "tag", "author", "feed" or "at-home") was requested
+func (s *MangaDexServer) Requests(endpoint string) int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.requests[endpoint]
+}
+
+func (s *MangaDexServer) serve(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	switch {
+	case len(parts) == 1 && parts[0] == "manga":
+		s.requests["search"]++
+		s.search(w, r)
+	case len(parts) == 2 && parts[0] == "manga" && parts[1] == "tag":
+		s.requests["tag"]++
+		s.tags(w)
+	case len(parts) == 1 && parts[0] == "author":
+		s.requests["author"]++
+		s.authors(w, r)
+	case len(parts) == 2 && parts[0] == "manga":
+		s.requests["manga"]++
+		s.mangaEntity(w, parts[1])
+	case len(parts) == 1 && parts[0] == "chapter":
+		s.requests["latest"]++
+		s.latest(w, r)
+	case len(parts) == 2 && parts[0] == "chapter":
+		s.requests["chapter"]++
+		s.chapterEntity(w, parts[1])
+	case len(parts) == 3 && parts[0] == "manga" && parts[2] == "feed":
+		s.requests["feed"]++
+		s.feed(w, r, parts[1])
+	case len(parts) == 3 && parts[0] == "at-home" && parts[1] == "server":
+		s.requests["at-home"]++
+		s.atHome(w, parts[2])
+	default:
+		mangaDexNotFound(w)
+	}
+}
+
+func (s *MangaDexServer) search(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	title := strings.ToLower(query.Get("title"))
+
+	matches := make([]*mangaDexServerManga, 0)
+	for _, manga := range s.mangas {
+		genres := map[string]bool{}
+		for _, genre := range manga.genres {
+			genres[mangaDexTagID(genre)] = true
+		}
+
+		switch {
+		case !strings.Contains(strings.ToLower(manga.title), title):
+		case !mangaDexAll(query["includedTags[]"], func(tag string) bool { return genres[tag] }):
+		case !mangaDexAll(query["excludedTags[]"], func(tag string) bool { return !genres[tag] }):
+		case len(query["status[]"]) > 0 && !mangaDexAny(query["status[]"], func(status string) bool { return status == manga.status }):
+		case len(query["authorOrArtist"]) > 0 && !mangaDexAny(query["authorOrArtist"], func(id string) bool { return id == mangaDexAuthorID(manga.author) }):
+		default:
+			matches = append(matches, manga)
+		}
+	}
+
+	if query.Get("order[title]") == "asc" {
+		sort.SliceStable(matches, func(i, j int) bool { return matches[i].title < matches[j].title })
+	}
+
+	limit, _ := strconv.Atoi(query.Get("limit"))
+	offset, _ := strconv.Atoi(query.Get("offset"))
+	if limit <= 0 {
+		limit = 10
+	}
+
+	data := make([]interface{}, 0)
+	for i := offset; i < len(matches) && i < offset+limit; i++ {
+		data = append(data, matches[i].data())
+	}
+
+	mangaDexJSON(w, map[string]interface{}{"result": "ok", "data": data, "limit": limit, "offset": offset, "total": len(matches)})
+}
+
+// mangaEntity answers a manga, its cover art is named after the manga ID
+func (s *MangaDexServer) mangaEntity(w http.ResponseWriter, id string) {
+	manga := s.manga(id)
+	if manga == nil {
+		mangaDexNotFound(w)
+		return
+	}
+
+	mangaDexJSON(w, map[string]interface{}{"result": "ok", "data": manga.data()})
+}
+
+func (s *MangaDexServer) chapterEntity(w http.ResponseWriter, id string) {
+	chapter, ok := s.chapters[id]
+	if !ok {
+		mangaDexNotFound(w)
+		return
+	}
+
+	mangaDexJSON(w, map[string]interface{}{"result": "ok", "data": chapter.data()})
+}
+
+// latest answers chapters by readable date, newest first
+func (s *MangaDexServer) latest(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	languages := map[string]bool{}
+	for _, language := range query["translatedLanguage[]"] {
+		languages[language] = true
+	}
+
+	chapters := make([]*mangaDexServerChapter, 0, len(s.chapters))
+	for _, chapter := range s.chapters {
+		if len(languages) == 0 || languages[chapter.language] {
+			chapters = append(chapters, chapter)
+		}
+	}
+	sort.Slice(chapters, func(i, j int) bool { return chapters[i].readableAt.After(chapters[j].readableAt) })
+
+	limit, _ := strconv.Atoi(query.Get("limit"))
+	offset, _ := strconv.Atoi(query.Get("offset"))
+	if limit <= 0 {
+		limit = 10
+	}
+
+	data := make([]interface{}, 0)
+	for i := offset; i < len(chapters) && i < offset+limit; i++ {
+		data = append(data, chapters[i].data())
+	}
+
+	mangaDexJSON(w, map[string]interface{}{"result": "ok", "data": data, "limit": limit, "offset": offset, "total": len(chapters)})
+}
+
+func (m *mangaDexServerManga) data() map[string]interface{} {
+	tags := make([]interface{}, 0, len(m.genres))
+	for _, genre := range m.genres {
+		tags = append(tags, map[string]interface{}{
+			"id":         mangaDexTagID(genre),
+			"attributes": map[string]interface{}{"name": map[string]string{"en": genre}, "group": "genre"},
+		})
+	}
+
+	return map[string]interface{}{
+		"id":   m.id,
+		"type": "manga",
+		"attributes": map[string]interface{}{
+			"title":  map[string]string{"en": m.title},
+			"tags":   tags,
+			"status": m.status,
+		},
+		"relationships": []interface{}{
+			map[string]interface{}{
+				"id":         mangaDexAuthorID(m.author),
+				"type":       "author",
+				"attributes": map[string]string{"name": m.author},
+			},
+			map[string]interface{}{
+				"id":         "cover-" + m.id,
+				"type":       "cover_art",
+				"attributes": map[string]string{"fileName": m.id + ".jpg"},
+			},
+		},
+	}
+}
+
+func (c *mangaDexServerChapter) data() map[string]interface{} {
+	return map[string]interface{}{
+		"id":   c.id,
+		"type": "chapter",
+		"attributes": map[string]interface{}{
+			"volume":             c.volumeData(),
+			"chapter":            c.number,
+			"title":              "",
+			"translatedLanguage": c.language,
+			"externalUrl":        nil,
+			"pages":              c.pages,
+			"readableAt":         c.readableAt.Format(time.RFC3339),
+		},
+		"relationships": []interface{}{map[string]interface{}{
+			"id":         c.manga.id,
+			"type":       "manga",
+			"attributes": map[string]interface{}{"title": map[string]string{"en": c.manga.title}},
+		}},
+	}
+}
+
+// volumeData returns chapter volume, null when it has none
+func (c *mangaDexServerChapter) volumeData() interface{} {
+	if len(c.volume) == 0 {
+		return nil
+	}
+	return c.volume
+}
+
+func (s *MangaDexServer) tags(w http.ResponseWriter) {
+	seen := map[string]bool{}
+	data := make([]interface{}, 0)
+	for _, manga := range s.mangas {
+		for _, genre := range manga.genres {
+			if seen[genre] {
+				continue
+			}
+			seen[genre] = true
+			data = append(data, map[string]interface{}{
+				"id":         mangaDexTagID(genre),
+				"attributes": map[string]interface{}{"name": map[string]string{"en": genre}, "group": "genre"},
+			})
+		}
+	}
+
+	mangaDexJSON(w, map[string]interface{}{"result": "ok", "data": data, "total": len(data)})
+}
+
+func (s *MangaDexServer) authors(w http.ResponseWriter, r *http.Request) {
+	name := strings.ToLower(r.URL.Query().Get("name"))
+
+	seen := map[string]bool{}
+	data := make([]interface{}, 0)
+	for _, manga := range s.mangas {
+		if seen[manga.author] || !strings.Contains(strings.ToLower(manga.author), name) {
+			continue
+		}
+		seen[manga.author] = true
+		data = append(data, map[string]interface{}{
+			"id":         mangaDexAuthorID(manga.author),
+			"attributes": map[string]interface{}{"name": manga.author},
+		})
+	}
+
+	mangaDexJSON(w, map[string]interface{}{"result": "ok", "data": data, "total": len(data)})
+}
+
+func mangaDexTagID(genre string) string {
+	return "tag-" + strings.ToLower(strings.Join(strings.Fields(genre), "-"))
+}
+
+func mangaDexAuthorID(author string) string {
+	return "author-" + strings.ToLower(strings.Join(strings.Fields(author), "-"))
+}
+
+func mangaDexAll(values []string, f func(string) bool) bool {
+	for _, value := range values {
+		if !f(value) {
+			return false
+		}
+	}
+	return true
+}
+
+func mangaDexAny(values []string, f func(string) bool) bool {
+	for _, value := range values {
+		if f(value) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *MangaDexServer) feed(w http.ResponseWriter, r *http.Request, id string) {
+	manga := s.manga(id)
+	if manga == nil {
+		mangaDexNotFound(w)
+		return
+	}
+
+	query := r.URL.Query()
+	languages := map[string]bool{}
+	for _, language := range query["translatedLanguage[]"] {
+		languages[language] = true
+	}
+
+	chapters := make([]*mangaDexServerChapter, 0)
+	for _, chapter := range manga.chapters {
+		if len(languages) == 0 || languages[chapter.language] {
+			chapters = append(chapters, chapter)
+		}
+	}
+
+	limit, _ := strconv.Atoi(query.Get("limit"))
+	offset, _ := strconv.Atoi(query.Get("offset"))
+	if limit <= 0 {
+		limit = 100
+	}
+	if s.PageSize > 0 && limit > s.PageSize {
+		limit = s.PageSize
+	}
+
+	data := make([]interface{}, 0)
+	for i := offset; i < len(chapters) && i < offset+limit; i++ {
+		data = append(data, chapters[i].data())
+	}
+
+	mangaDexJSON(w, map[string]interface{}{
+		"result": "ok",
+		"data":   data,
+		"limit":  limit,
+		"offset": offset,
+		"total":  len(chapters),
+	})
+}
+
+func (s *MangaDexServer) atHome(w http.ResponseWriter, id string) {
+	chapter, ok := s.chapters[id]
+	if !ok {
+		mangaDexNotFound(w)
+		return
+	}
+
+	files := make([]string, 0, chapter.pages)
+	for p := 1; p <= chapter.pages; p++ {
+		files = append(files, fmt.Sprintf("%d.png", p))
+	}
+
+	mangaDexJSON(w, map[string]interface{}{
+		"result":  "ok",
+		"baseUrl": s.Images.URL,
+		"chapter": map[string]interface{}{
+			"hash":      chapter.id,
+			"data":      files,
+			"dataSaver": files,
+		},
+	})
+}
+
+func mangaDexJSON(w http.ResponseWriter, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(value)
+}
+
+func mangaDexNotFound(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusNotFound)
+	json.NewEncoder(w).Encode(map[string]interface{}{"result": "error", "errors": []interface{}{}})
+}
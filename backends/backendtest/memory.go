@@ -0,0 +1,163 @@
+package backendtest
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/toxinu/katago/backends"
+	"github.com/toxinu/katago/client"
+)
+
+// Memory is an in-memory Backend whose page images are served by an
+// ImageServer
+type Memory struct {
+	Images *ImageServer
+	// Errors makes methods called with given manga, chapter or page URL fail
+	Errors map[string]error
+
+	mutex    sync.Mutex
+	mangas   []*backends.Manga
+	chapters map[string][]*backends.Chapter
+	pages    map[string][]*backends.Page
+	calls    map[string]int
+}
+
+// NewMemory returns an empty Memory backend
+func NewMemory(images *ImageServer) *Memory {
+	return &Memory{
+		Images:   images,
+		Errors:   map[string]error{},
+		chapters: map[string][]*backends.Chapter{},
+		pages:    map[string][]*backends.Page{},
+		calls:    map[string]int{},
+	}
+}
+
+// AddManga adds a manga having given number of chapters and pages per chapter
+func (m *Memory) AddManga(name string, chapterCount int, pageCount int) *backends.Manga {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	slug := strings.ToLower(strings.Join(strings.Fields(name), "-"))
+	manga := &backends.Manga{
+		ID:   slug,
+		Name: name,
+		Slug: slug,
+		URL:  &url.URL{Scheme: "memory", Host: "katago", Path: "/manga/" + slug},
+	}
+	m.mangas = append(m.mangas, manga)
+
+	for c := 1; c <= chapterCount; c++ {
+		chapter := &backends.Chapter{
+			Name: fmt.Sprintf("%s %d", name, c),
+			URL:  &url.URL{Scheme: "memory", Host: "katago", Path: fmt.Sprintf("/manga/%s/c%03d", slug, c)},
+		}
+		m.chapters[manga.URL.String()] = append(m.chapters[manga.URL.String()], chapter)
+
+		for p := 1; p <= pageCount; p++ {
+			page := &backends.Page{
+				URL: &url.URL{Scheme: "memory", Host: "katago", Path: fmt.Sprintf("/manga/%s/c%03d/%d", slug, c, p)},
+			}
+			m.pages[chapter.URL.String()] = append(m.pages[chapter.URL.String()], page)
+		}
+	}
+
+	return manga
+}
+
+// ImagePath returns the ImageServer path of given page image
+func (m *Memory) ImagePath(page *backends.Page) string {
+	return strings.TrimPrefix(page.URL.Path, "/manga") + ".png"
+}
+
+// Calls returns how many times given method was called
+func (m *Memory) Calls(method string) int {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.calls[method]
+}
+
+func (m *Memory) call(method string, u *url.URL) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.calls[method]++
+	if u == nil {
+		return nil
+	}
+	return m.Errors[u.String()]
+}
+
+// Name implements Backend interface
+func (*Memory) Name() string {
+	return "Memory"
+}
+
+// Search implements Backend interface
+func (m *Memory) Search(query *backends.Query) ([]*backends.Manga, error) {
+	err := m.call("Search", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	results := make([]*backends.Manga, 0)
+	for _, manga := range m.mangas {
+		if strings.Contains(strings.ToLower(manga.Name), strings.ToLower(query.Term)) {
+			results = append(results, manga)
+		}
+	}
+	return results, nil
+}
+
+// Chapters implements Backend interface
+func (m *Memory) Chapters(manga *backends.Manga) ([]*backends.Chapter, error) {
+	err := m.call("Chapters", manga.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	chapters, ok := m.chapters[manga.URL.String()]
+	if !ok {
+		return nil, &client.StatusError{Code: 404, URL: manga.URL}
+	}
+	return chapters, nil
+}
+
+// Pages implements Backend interface
+func (m *Memory) Pages(chapter *backends.Chapter) ([]*backends.Page, error) {
+	err := m.call("Pages", chapter.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	pages, ok := m.pages[chapter.URL.String()]
+	if !ok {
+		return nil, &client.StatusError{Code: 404, URL: chapter.URL}
+	}
+	return pages, nil
+}
+
+// PageImageURL implements Backend interface
+func (m *Memory) PageImageURL(page *backends.Page) (*url.URL, error) {
+	err := m.call("PageImageURL", page.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	if page.URL.Scheme != "memory" {
+		return nil, &client.StatusError{Code: 404, URL: page.URL}
+	}
+
+	return url.Parse(m.Images.URL + m.ImagePath(page))
+}
@@ -0,0 +1,104 @@
+package backendtest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+
+	"github.com/toxinu/katago/client"
+)
+
+// originalURLHeader carries the URL a request was sent to before being
+// routed to the fixture server
+const originalURLHeader = "X-Katago-Original-URL"
+
+// Server replays a Cassette through httptest, whatever host was requested
+type Server struct {
+	*httptest.Server
+	Cassette *client.Cassette
+}
+
+// NewServer starts a Server replaying given Cassette
+func NewServer(cassette *client.Cassette) *Server {
+	s := &Server{Cassette: cassette}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+// LoadServer starts a Server replaying the Cassette stored at given path
+func LoadServer(path string) (*Server, error) {
+	cassette, err := client.LoadCassette(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewServer(cassette), nil
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	requestURL := r.Header.Get(originalURLHeader)
+	if len(requestURL) == 0 {
+		requestURL = (&url.URL{Scheme: "http", Host: r.Host, Path: r.URL.Path, RawQuery: r.URL.RawQuery}).String()
+	}
+
+	interaction, ok := s.Cassette.Find(r.Method, requestURL)
+	if !ok {
+		http.Error(w, "no recorded interaction for "+requestURL, http.StatusNotFound)
+		return
+	}
+
+	body, err := interaction.Response.Bytes()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	for key, values := range interaction.Response.Header {
+		if key == "Content-Length" || key == "Transfer-Encoding" {
+			continue
+		}
+		for _, value := range values {
+			w.Header().Add(key, value)
+		}
+	}
+	w.WriteHeader(interaction.Response.StatusCode)
+	w.Write(body)
+}
+
+// Client returns a Client whose requests are all routed to the Server
+func (s *Server) Client() *client.Client {
+	target, _ := url.Parse(s.URL)
+
+	c := client.NewClient()
+	c.Limiter = nil
+	c.Retry.MaxAttempts = 1
+	c.HTTPClient = &http.Client{Transport: &routeTransport{target: target, transport: s.Server.Client().Transport}}
+	return c
+}
+
+// routeTransport sends every request to target, keeping the original URL
+// in a header, but requests to keep host which are sent as is
+type routeTransport struct {
+	target    *url.URL
+	transport http.RoundTripper
+	keep      string
+}
+
+// RoundTrip implements http.RoundTripper interface
+func (t *routeTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if len(t.keep) > 0 && req.URL.Host == t.keep {
+		return t.transport.RoundTrip(req)
+	}
+
+	routed := req.Clone(req.Context())
+	routed.Header.Set(originalURLHeader, req.URL.String())
+	routed.URL.Scheme = t.target.Scheme
+	routed.URL.Host = t.target.Host
+	routed.Host = ""
+
+	resp, err := t.transport.RoundTrip(routed)
+	if err != nil {
+		return nil, err
+	}
+	resp.Request = req
+	return resp, nil
+}
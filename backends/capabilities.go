@@ -0,0 +1,107 @@
+package backends
+
+import (
+	"net/url"
+)
+
+// Capability is an optional backend feature
+type Capability string
+
+// Capabilities backends may offer, each one comes with an optional interface
+const (
+	// CapabilityLogin backends implement Authenticator
+	CapabilityLogin Capability = "login"
+	// CapabilityFilters backends implement Filterer and support at least one
+	// filter
+	CapabilityFilters Capability = "filters"
+	// CapabilityLanguages backends implement Multilingual
+	CapabilityLanguages Capability = "languages"
+	// CapabilityCovers backends implement CoverProvider
+	CapabilityCovers Capability = "covers"
+	// CapabilityLinks backends implement Linker and own at least one URL
+	// pattern
+	CapabilityLinks Capability = "links"
+	// CapabilityLatest backends implement Updater
+	CapabilityLatest Capability = "latest"
+)
+
+// AllCapabilities lists capabilities in display order
+var AllCapabilities = []Capability{
+	CapabilityLogin,
+	CapabilityFilters,
+	CapabilityLanguages,
+	CapabilityCovers,
+	CapabilityLinks,
+	CapabilityLatest,
+}
+
+// Multilingual is implemented by backends offering chapters in several
+// languages
+type Multilingual interface {
+	// ChapterLanguages returns the languages chapters are picked in, by
+	// order of preference
+	ChapterLanguages() []string
+}
+
+// CoverProvider is implemented by backends knowing manga cover art
+type CoverProvider interface {
+	CoverURL(*Manga) (*url.URL, error)
+}
+
+// Supporter is implemented by backends whose capabilities depend on their
+// configuration, like definitions, to withdraw the ones their optional
+// interfaces suggest
+type Supporter interface {
+	Supports(Capability) bool
+}
+
+// Has tells whether given backend offers given capability, callers should
+// ask before type asserting optional interfaces
+func Has(b Backend, capability Capability) bool {
+	if supporter, ok := b.(Supporter); ok && !supporter.Supports(capability) {
+		return false
+	}
+
+	switch capability {
+	case CapabilityLogin:
+		_, ok := b.(Authenticator)
+		return ok
+	case CapabilityFilters:
+		return !SupportedFilters(b).Empty()
+	case CapabilityLanguages:
+		multilingual, ok := b.(Multilingual)
+		return ok && len(multilingual.ChapterLanguages()) > 0
+	case CapabilityCovers:
+		_, ok := b.(CoverProvider)
+		return ok
+	case CapabilityLinks:
+		linker, ok := b.(Linker)
+		return ok && len(linker.URLPatterns()) > 0
+	case CapabilityLatest:
+		_, ok := b.(Updater)
+		return ok
+	default:
+		return false
+	}
+}
+
+// Capabilities returns the capabilities given backend offers, in
+// AllCapabilities order
+func Capabilities(b Backend) []Capability {
+	capabilities := make([]Capability, 0, len(AllCapabilities))
+	for _, capability := range AllCapabilities {
+		if Has(b, capability) {
+			capabilities = append(capabilities, capability)
+		}
+	}
+	return capabilities
+}
+
+// CoverURL returns given manga cover art URL, nil when its backend does not
+// know covers
+func CoverURL(b Backend, manga *Manga) (*url.URL, error) {
+	if !Has(b, CapabilityCovers) {
+		return nil, nil
+	}
+	return b.(CoverProvider).CoverURL(manga)
+}
@@ -0,0 +1,335 @@
+package backends
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/toxinu/katago/client"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Page enumeration strategies
+const (
+	// PagesLinks reads every page URL from chapter page items
+	PagesLinks = "links"
+	// PagesTemplate builds page URLs from chapter page items values
+	PagesTemplate = "template"
+	// PagesImages reads every image from chapter page, pages are the images
+	PagesImages = "images"
+)
+
+// Chapters orders
+const (
+	OldestFirst = "oldest_first"
+	NewestFirst = "newest_first"
+)
+
+// Definition describes a site scraped by a Scraper backend
+type Definition struct {
+	Name    string          `json:"name" yaml:"name"`
+	Slug    string          `json:"slug" yaml:"slug"`
+	BaseURL string          `json:"base_url" yaml:"base_url"`
+	Limit   DefinitionLimit `json:"limit" yaml:"limit"`
+
+	Search   SearchDefinition   `json:"search" yaml:"search"`
+	Chapters ChaptersDefinition `json:"chapters" yaml:"chapters"`
+	Pages    PagesDefinition    `json:"pages" yaml:"pages"`
+	Image    Field              `json:"image" yaml:"image"`
+	URLRules URLRules           `json:"url_rules" yaml:"url_rules"`
+	Links    LinksDefinition    `json:"links" yaml:"links"`
+	Latest   LatestDefinition   `json:"latest" yaml:"latest"`
+}
+
+// DefinitionLimit represents site politeness rules
+type DefinitionLimit struct {
+	Rate           float64 `json:"rate" yaml:"rate"`
+	Burst          int     `json:"burst" yaml:"burst"`
+	MaxConnections int     `json:"max_connections" yaml:"max_connections"`
+}
+
+// SearchDefinition describes how to search a site
+type SearchDefinition struct {
+	// URL is a template where {base} is BaseURL, {term} the escaped term and
+	// {page} the results page starting at 1
+	URL string `json:"url" yaml:"url"`
+	// Format is "html" or "json"
+	Format string `json:"format" yaml:"format"`
+	// Results selects result items, in json format it is a dotted path
+	Results string `json:"results" yaml:"results"`
+	Name    Field  `json:"name" yaml:"name"`
+	Link    Field  `json:"link" yaml:"link"`
+	Author  Field  `json:"author" yaml:"author"`
+	Genre   Field  `json:"genre" yaml:"genre"`
+}
+
+// ChaptersDefinition describes how to list a manga chapters
+type ChaptersDefinition struct {
+	// Items selects chapter items, several selectors can be joined with a comma
+	Items string `json:"items" yaml:"items"`
+	Name  Field  `json:"name" yaml:"name"`
+	Link  Field  `json:"link" yaml:"link"`
+	// Order is how the site lists chapters, OldestFirst or NewestFirst
+	Order string `json:"order" yaml:"order"`
+}
+
+// PagesDefinition describes how to enumerate a chapter pages
+type PagesDefinition struct {
+	// Strategy is PagesLinks, PagesTemplate or PagesImages
+	Strategy string `json:"strategy" yaml:"strategy"`
+	Items    string `json:"items" yaml:"items"`
+	Value    Field  `json:"value" yaml:"value"`
+	// URL is a template for PagesTemplate where {chapter} is chapter URL,
+	// {chapter_dir} the chapter URL up to its last slash and {value} the
+	// item value
+	URL string `json:"url" yaml:"url"`
+	// Skip ignores items whose value matches this regexp
+	Skip string `json:"skip" yaml:"skip"`
+
+	skip *regexp.Regexp
+}
+
+// Field extracts a value from an item
+type Field struct {
+	// Selector is relative to the item, the item itself when empty
+	Selector string `json:"selector" yaml:"selector"`
+	// Attribute is read instead of the node text when set
+	Attribute string `json:"attribute" yaml:"attribute"`
+	// Key is a dotted path read in json items
+	Key string `json:"key" yaml:"key"`
+	// Regexp keeps its first group from the value when set
+	Regexp string `json:"regexp" yaml:"regexp"`
+	// Template is a pattern where {value} is the value, like "/manga/{value}/"
+	Template string `json:"template" yaml:"template"`
+
+	regexp *regexp.Regexp
+}
+
+// LinksDefinition describes the site URLs a Scraper resolves
+type LinksDefinition struct {
+	// Patterns are regexps matching manga, chapter and page URLs, the named
+	// groups "manga" and "chapter" end where manga and chapter URLs end and
+	// "page" tells the URL is a page
+	Patterns []string `json:"patterns" yaml:"patterns"`
+	// Title reads the manga name on manga page, the name is made from the
+	// manga URL when unset
+	Title Field `json:"title" yaml:"title"`
+
+	patterns []*regexp.Regexp
+}
+
+// LatestDefinition describes a site list of recently published chapters
+type LatestDefinition struct {
+	// URL is a template where {base} is BaseURL and {page} the page starting
+	// at 1, sites without one do not list latest updates
+	URL string `json:"url" yaml:"url"`
+	// Items selects published chapter items
+	Items       string `json:"items" yaml:"items"`
+	MangaName   Field  `json:"manga_name" yaml:"manga_name"`
+	MangaLink   Field  `json:"manga_link" yaml:"manga_link"`
+	ChapterName Field  `json:"chapter_name" yaml:"chapter_name"`
+	ChapterLink Field  `json:"chapter_link" yaml:"chapter_link"`
+	// Date is read with DateLayout, a Go time layout like "Jan 2, 2006"
+	Date       Field  `json:"date" yaml:"date"`
+	DateLayout string `json:"date_layout" yaml:"date_layout"`
+}
+
+// URLRules normalises URLs read from a site
+type URLRules struct {
+	// Replace rewrites matching URLs before they are resolved
+	Replace []URLReplace `json:"replace" yaml:"replace"`
+	// StripQuery removes URLs query string
+	StripQuery bool `json:"strip_query" yaml:"strip_query"`
+}
+
+// URLReplace replaces Pattern matches with With
+type URLReplace struct {
+	Pattern string `json:"pattern" yaml:"pattern"`
+	With    string `json:"with" yaml:"with"`
+
+	pattern *regexp.Regexp
+}
+
+// LoadDefinition reads a JSON or YAML definition file
+func LoadDefinition(path string) (*Definition, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &Definition{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		// unknown fields are rejected as YAML ones are, a misspelled key
+		// would otherwise leave its setting out silently
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(d)
+	case ".yaml", ".yml":
+		err = yaml.UnmarshalStrict(data, d)
+	default:
+		return nil, fmt.Errorf("invalid definition %s: unknown format", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid definition %s: %s", path, err)
+	}
+
+	err = d.compile()
+	if err != nil {
+		return nil, fmt.Errorf("invalid definition %s: %s", path, err)
+	}
+	return d, nil
+}
+
+// LoadDefinitions reads every definition file of given directory, invalid
+// files are skipped and returned as errors
+func LoadDefinitions(dir string) ([]*Definition, []error) {
+	files, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, []error{err}
+	}
+
+	var errs []error
+	definitions := make([]*Definition, 0, len(files))
+	for _, file := range files {
+		switch strings.ToLower(filepath.Ext(file.Name())) {
+		case ".json", ".yaml", ".yml":
+		default:
+			continue
+		}
+
+		d, err := LoadDefinition(filepath.Join(dir, file.Name()))
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		definitions = append(definitions, d)
+	}
+	return definitions, errs
+}
+
+func (d *Definition) compile() error {
+	var err error
+
+	if len(d.Name) == 0 || len(d.Slug) == 0 {
+		return errors.New("name and slug are required")
+	}
+	if len(d.Search.URL) == 0 || len(d.Chapters.Items) == 0 {
+		return errors.New("search url and chapters items are required")
+	}
+
+	switch d.Search.Format {
+	case "":
+		d.Search.Format = "html"
+	case "html", "json":
+	default:
+		return fmt.Errorf("unknown search format '%s'", d.Search.Format)
+	}
+	if d.Search.Format == "html" && len(d.Search.Results) == 0 {
+		return errors.New("search results selector is required by html format")
+	}
+
+	switch d.Chapters.Order {
+	case "":
+		d.Chapters.Order = OldestFirst
+	case OldestFirst, NewestFirst:
+	default:
+		return fmt.Errorf("unknown chapters order '%s'", d.Chapters.Order)
+	}
+
+	switch d.Pages.Strategy {
+	case PagesLinks, PagesImages:
+	case PagesTemplate:
+		if len(d.Pages.URL) == 0 {
+			return errors.New("pages url is required by template strategy")
+		}
+	default:
+		return fmt.Errorf("unknown pages strategy '%s'", d.Pages.Strategy)
+	}
+	if d.Pages.Strategy != PagesImages && len(d.Image.Selector) == 0 {
+		return errors.New("image selector is required")
+	}
+
+	if len(d.Latest.URL) > 0 && len(d.Latest.Items) == 0 {
+		return errors.New("latest items are required by latest url")
+	}
+
+	defaultAttribute(&d.Search.Link, "href")
+	defaultAttribute(&d.Chapters.Link, "href")
+	defaultAttribute(&d.Image, "src")
+	defaultAttribute(&d.Latest.MangaLink, "href")
+	defaultAttribute(&d.Latest.ChapterLink, "href")
+	switch d.Pages.Strategy {
+	case PagesLinks:
+		defaultAttribute(&d.Pages.Value, "href")
+	case PagesTemplate:
+		defaultAttribute(&d.Pages.Value, "value")
+	case PagesImages:
+		defaultAttribute(&d.Pages.Value, "src")
+	}
+
+	if len(d.Pages.Skip) > 0 {
+		d.Pages.skip, err = regexp.Compile(d.Pages.Skip)
+		if err != nil {
+			return err
+		}
+	}
+
+	fields := []*Field{
+		&d.Search.Name, &d.Search.Link, &d.Search.Author, &d.Search.Genre,
+		&d.Chapters.Name, &d.Chapters.Link, &d.Pages.Value, &d.Image,
+		&d.Links.Title, &d.Latest.MangaName, &d.Latest.MangaLink,
+		&d.Latest.ChapterName, &d.Latest.ChapterLink, &d.Latest.Date,
+	}
+	for _, field := range fields {
+		if len(field.Regexp) == 0 {
+			continue
+		}
+		field.regexp, err = regexp.Compile(field.Regexp)
+		if err != nil {
+			return err
+		}
+	}
+
+	d.Links.patterns = make([]*regexp.Regexp, 0, len(d.Links.Patterns))
+	for _, raw := range d.Links.Patterns {
+		pattern, err := regexp.Compile(raw)
+		if err != nil {
+			return err
+		}
+		if !stringSliceContains(pattern.SubexpNames(), "manga") {
+			return fmt.Errorf("links pattern '%s' has no manga group", raw)
+		}
+		d.Links.patterns = append(d.Links.patterns, pattern)
+	}
+
+	for i := range d.URLRules.Replace {
+		d.URLRules.Replace[i].pattern, err = regexp.Compile(d.URLRules.Replace[i].Pattern)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// defaultAttribute reads given attribute when field reads nothing else
+func defaultAttribute(field *Field, attribute string) {
+	if len(field.Attribute) == 0 && len(field.Key) == 0 && len(field.Regexp) == 0 {
+		field.Attribute = attribute
+	}
+}
+
+func (d *Definition) clientLimit() client.Limit {
+	return client.Limit{Rate: d.Limit.Rate, Burst: d.Limit.Burst, MaxConnections: d.Limit.MaxConnections}
+}
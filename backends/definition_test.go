@@ -0,0 +1,80 @@
+package backends_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/toxinu/katago/backends"
+)
+
+func TestLoadDefinitionsSkipsInvalidFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "katago-definitions-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	valid, err := ioutil.ReadFile("../definitions/mangafox.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"mangafox.yaml": string(valid),
+		"broken.yaml":   "name: [",
+		"results.yaml": `name: No results
+slug: no-results
+search:
+  url: "{base}/search?q={term}"
+chapters:
+  items: "ul.chapters a"
+pages:
+  strategy: images
+  items: "img.page"
+`,
+		"notes.txt": "not a definition",
+	}
+	for name, content := range files {
+		err = ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	definitions, errs := backends.LoadDefinitions(dir)
+	if len(definitions) != 1 || definitions[0].Slug != "mangafox-definition" {
+		t.Errorf("got %d definitions, want the valid one only", len(definitions))
+	}
+	if len(errs) != 2 {
+		t.Fatalf("got errors %v, want broken.yaml and results.yaml ones", errs)
+	}
+	for index, name := range []string{"broken.yaml", "results.yaml"} {
+		if !strings.Contains(errs[index].Error(), name) {
+			t.Errorf("error %q does not name %s", errs[index], name)
+		}
+	}
+	if !strings.Contains(errs[1].Error(), "search results selector is required") {
+		t.Errorf("got %q, want empty html results to be rejected", errs[1])
+	}
+}
+
+func TestLoadDefinitionRejectsUnknownJSONFields(t *testing.T) {
+	dir, err := ioutil.TempDir("", "katago-definitions-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "typo.json")
+	err = ioutil.WriteFile(path, []byte(`{"name": "Typo", "slug": "typo", "serach": {"url": "{base}/search?q={term}"}}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = backends.LoadDefinition(path)
+	if err == nil || !strings.Contains(err.Error(), `unknown field "serach"`) {
+		t.Errorf("got %v, want the misspelled field to be rejected", err)
+	}
+}
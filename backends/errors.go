@@ -0,0 +1,27 @@
+package backends
+
+import (
+	"fmt"
+	"net/url"
+)
+
+// ParseError is returned when a backend cannot understand a page
+type ParseError struct {
+	Backend string
+	// Selector is the HTML selector which matched nothing, if any
+	Selector string
+	URL      *url.URL
+	Err      error
+}
+
+func (e *ParseError) Error() string {
+	if len(e.Selector) > 0 {
+		return fmt.Sprintf("%s: html node '%s' not found in '%s'", e.Backend, e.Selector, e.URL)
+	}
+	return fmt.Sprintf("%s: cannot parse '%s': %s", e.Backend, e.URL, e.Err)
+}
+
+// Unwrap returns underlying error
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,163 @@
+package backends
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// SearchAllTimeout is how long SearchAll waits for each backend by default
+const SearchAllTimeout = 15 * time.Second
+
+// Source is a manga as found on one backend
+type Source struct {
+	// Backend is the slug the backend is registered with
+	Backend string
+	Manga   *Manga
+}
+
+// Series is a manga carried by one or more backends
+type Series struct {
+	Name    string
+	Author  string
+	Sources []*Source
+}
+
+// Source returns the first series source on given backend, nil if it has
+// none
+func (s *Series) Source(backend string) *Source {
+	for _, source := range s.Sources {
+		if source.Backend == backend {
+			return source
+		}
+	}
+	return nil
+}
+
+// Backends returns the slugs of the backends carrying the series, once
+// each
+func (s *Series) Backends() []string {
+	slugs := make([]string, 0, len(s.Sources))
+	for _, source := range s.Sources {
+		if !stringSliceContains(slugs, source.Backend) {
+			slugs = append(slugs, source.Backend)
+		}
+	}
+	return slugs
+}
+
+// SearchTimeoutError is returned for a backend not answering a search in time
+type SearchTimeoutError struct {
+	Backend string
+	Timeout time.Duration
+}
+
+func (e *SearchTimeoutError) Error() string {
+	return fmt.Sprintf("%s did not answer within %s", e.Backend, e.Timeout)
+}
+
+// SearchAll runs the Query on given backends concurrently and groups their
+// results by series, the ones carried by most backends first, keeping every
+// result of a backend. Each backend search is cancelled after timeout, and
+// backends failing, not supporting the Query or not answering in time are
+// reported in errors keyed by slug.
+func SearchAll(backends map[string]Backend, query *Query, timeout time.Duration) ([]*Series, map[string]error) {
+	type searchResult struct {
+		slug    string
+		results []*Manga
+		err     error
+	}
+
+	var (
+		wg       sync.WaitGroup
+		outcomes = make(chan searchResult, len(backends))
+	)
+
+	for slug, b := range backends {
+		wg.Add(1)
+		go func(slug string, b Backend) {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(query.Context(), timeout)
+			defer cancel()
+
+			// backends ignoring the context are not waited for past it
+			done := make(chan searchResult, 1)
+			go func() {
+				results, err := Search(b, query.WithContext(ctx))
+				done <- searchResult{slug: slug, results: results, err: err}
+			}()
+
+			var outcome searchResult
+			select {
+			case outcome = <-done:
+			case <-ctx.Done():
+				outcome = searchResult{slug: slug, err: ctx.Err()}
+			}
+			if outcome.err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				outcome.err = &SearchTimeoutError{Backend: slug, Timeout: timeout}
+			}
+			outcomes <- outcome
+		}(slug, b)
+	}
+	wg.Wait()
+	close(outcomes)
+
+	found := map[string][]*Manga{}
+	errs := map[string]error{}
+	for outcome := range outcomes {
+		if outcome.err != nil {
+			errs[outcome.slug] = outcome.err
+			continue
+		}
+		found[outcome.slug] = outcome.results
+	}
+
+	slugs := make([]string, 0, len(found))
+	for slug := range found {
+		slugs = append(slugs, slug)
+	}
+	sort.Strings(slugs)
+
+	series := make([]*Series, 0)
+	for _, slug := range slugs {
+		for _, manga := range found[slug] {
+			s := findSeries(series, manga)
+			if s == nil {
+				s = &Series{Name: manga.Name}
+				series = append(series, s)
+			}
+			if len(s.Author) == 0 {
+				s.Author = manga.Author
+			}
+			s.Sources = append(s.Sources, &Source{Backend: slug, Manga: manga})
+		}
+	}
+
+	sort.SliceStable(series, func(i, j int) bool { return len(series[i].Backends()) > len(series[j].Backends()) })
+	return series, errs
+}
+
+// findSeries returns the series given manga belongs to
+func findSeries(series []*Series, manga *Manga) *Series {
+	for _, s := range series {
+		if SameSeries(&Manga{Name: s.Name, Author: s.Author}, manga) {
+			return s
+		}
+	}
+	return nil
+}
+
+// normaliseName lowercases given name and keeps its letters and digits
+// only, so "Naruto: Shippuden" and "naruto shippuden" compare equal
+func normaliseName(name string) string {
+	words := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return strings.Join(words, " ")
+}
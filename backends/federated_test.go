@@ -0,0 +1,120 @@
+package backends_test
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/toxinu/katago/backends"
+	"github.com/toxinu/katago/backends/backendtest"
+	"github.com/toxinu/katago/client"
+)
+
+// searcher is a Memory backend whose searches are run by search
+type searcher struct {
+	*backendtest.Memory
+	search func(*backends.Query) ([]*backends.Manga, error)
+}
+
+func (s *searcher) Search(query *backends.Query) ([]*backends.Manga, error) {
+	return s.search(query)
+}
+
+func TestSearchAllGroupsSeries(t *testing.T) {
+	a := backendtest.NewMemory(nil)
+	a.AddManga("Berserk", 1, 1)
+	a.AddManga("Claymore", 1, 1)
+	// Berserk! is the same series under another title, kept as a second
+	// source of a
+	a.AddManga("Berserk!", 1, 1)
+	b := backendtest.NewMemory(nil)
+	b.AddManga("berserk", 1, 1)
+
+	series, errs := backends.SearchAll(map[string]backends.Backend{"a": a, "b": b}, backends.NewQuery(""), time.Second)
+	if len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	if len(series) != 2 {
+		t.Fatalf("got %d series, want 2", len(series))
+	}
+
+	berserk, claymore := series[0], series[1]
+	if berserk.Name != "Berserk" || claymore.Name != "Claymore" {
+		t.Fatalf("got series %s and %s, want Berserk first and Claymore", berserk.Name, claymore.Name)
+	}
+	var names []string
+	for _, source := range berserk.Sources {
+		names = append(names, source.Backend+": "+source.Manga.Name)
+	}
+	if want := []string{"a: Berserk", "a: Berserk!", "b: berserk"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("got Berserk sources %v, want %v", names, want)
+	}
+	if got := berserk.Backends(); !reflect.DeepEqual(got, []string{"a", "b"}) {
+		t.Errorf("got Berserk backends %v, want [a b]", got)
+	}
+	if source := berserk.Source("b"); source == nil || source.Manga.Name != "berserk" {
+		t.Errorf("got source %v on b, want berserk", source)
+	}
+	if got := claymore.Backends(); !reflect.DeepEqual(got, []string{"a"}) {
+		t.Errorf("got Claymore backends %v, want [a]", got)
+	}
+}
+
+func TestSearchAllCancelsSlowBackends(t *testing.T) {
+	fast := backendtest.NewMemory(nil)
+	fast.AddManga("Berserk", 1, 1)
+
+	stopped := make(chan struct{})
+	slow := &searcher{Memory: backendtest.NewMemory(nil), search: func(query *backends.Query) ([]*backends.Manga, error) {
+		<-query.Context().Done()
+		close(stopped)
+		return nil, query.Context().Err()
+	}}
+
+	timeout := 50 * time.Millisecond
+	series, errs := backends.SearchAll(map[string]backends.Backend{"fast": fast, "slow": slow}, backends.NewQuery("berserk"), timeout)
+
+	var timeoutError *backends.SearchTimeoutError
+	if !errors.As(errs["slow"], &timeoutError) || timeoutError.Backend != "slow" || timeoutError.Timeout != timeout {
+		t.Errorf("got error %v for slow, want a SearchTimeoutError", errs["slow"])
+	}
+	if len(series) != 1 || !reflect.DeepEqual(series[0].Backends(), []string{"fast"}) {
+		t.Errorf("got series %v, want Berserk from fast", series)
+	}
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Error("slow search was not cancelled")
+	}
+}
+
+func TestSearchAllReportsPartialErrors(t *testing.T) {
+	working := backendtest.NewMemory(nil)
+	working.AddManga("Berserk", 1, 1)
+
+	cause := &client.StatusError{Code: http.StatusInternalServerError}
+	failing := &searcher{Memory: backendtest.NewMemory(nil), search: func(*backends.Query) ([]*backends.Manga, error) {
+		return nil, cause
+	}}
+
+	query := backends.NewQuery("berserk")
+	query.Page = 2
+	paging := &searcher{Memory: backendtest.NewMemory(nil), search: func(*backends.Query) ([]*backends.Manga, error) {
+		return []*backends.Manga{}, nil
+	}}
+
+	series, errs := backends.SearchAll(map[string]backends.Backend{"working": working, "failing": failing}, backends.NewQuery("berserk"), time.Second)
+	if len(errs) != 1 || errs["failing"] != cause {
+		t.Errorf("got errors %v, want failing one only", errs)
+	}
+	if len(series) != 1 || series[0].Name != "Berserk" {
+		t.Errorf("got series %v, want Berserk from working", series)
+	}
+
+	_, errs = backends.SearchAll(map[string]backends.Backend{"paging": paging}, query, time.Second)
+	if !errors.Is(errs["paging"], backends.ErrUnsupportedFilter) {
+		t.Errorf("got error %v, want an unsupported filter", errs["paging"])
+	}
+}
@@ -0,0 +1,79 @@
+package backends
+
+import (
+	"fmt"
+	"time"
+)
+
+// Update is a chapter recently published on a backend
+type Update struct {
+	Manga   *Manga
+	Chapter *Chapter
+	// Date is when the chapter was published, zero when unknown
+	Date time.Time
+}
+
+// Updater is implemented by backends listing recently published chapters
+type Updater interface {
+	// Latest returns recently published chapters, newest first, page starts
+	// at 1
+	Latest(page int) ([]*Update, error)
+}
+
+// Latest returns given backend recently published chapters
+func Latest(b Backend, page int) ([]*Update, error) {
+	if !Has(b, CapabilityLatest) {
+		return nil, fmt.Errorf("%s does not list latest updates", b.Name())
+	}
+	return b.(Updater).Latest(page)
+}
+
+// FollowedUpdate is an update of a manga found in a library
+type FollowedUpdate struct {
+	*Update
+	// Local is the manga in the library
+	Local *Manga
+	// Downloaded tells the library already has the chapter, see FindChapter
+	Downloaded bool
+}
+
+// FollowUpdates keeps the updates of mangas found in given library backend,
+// usually Local, whose chapters are listed once per manga instead of asking
+// every followed manga chapters to the updated backend
+func FollowUpdates(library Backend, updates []*Update) ([]*FollowedUpdate, error) {
+	mangas, err := library.Search(NewQuery(""))
+	if err != nil {
+		return nil, err
+	}
+
+	downloaded := map[*Manga][]*Chapter{}
+	followed := make([]*FollowedUpdate, 0)
+	for _, update := range updates {
+		var local *Manga
+		for _, manga := range mangas {
+			if SameSeries(manga, update.Manga) {
+				local = manga
+				break
+			}
+		}
+		if local == nil {
+			continue
+		}
+
+		chapters, ok := downloaded[local]
+		if !ok {
+			chapters, err = library.Chapters(local)
+			if err != nil {
+				return nil, err
+			}
+			downloaded[local] = chapters
+		}
+
+		followed = append(followed, &FollowedUpdate{
+			Update:     update,
+			Local:      local,
+			Downloaded: FindChapter(chapters, update.Chapter) != nil,
+		})
+	}
+	return followed, nil
+}
@@ -0,0 +1,187 @@
+package backends
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/toxinu/katago/client"
+)
+
+// ErrUnknownURL is returned for URLs no backend owns
+var ErrUnknownURL = errors.New("no backend handles this URL")
+
+var regexpLinkPageFile = regexp.MustCompile(`/\d+\.html$`)
+
+// Link is what an URL points to, Chapter and Page are nil when it points to
+// a manga or a chapter
+type Link struct {
+	Manga   *Manga
+	Chapter *Chapter
+	Page    *Page
+}
+
+// Linker is implemented by backends resolving links to their site
+type Linker interface {
+	// URLPatterns match the URLs the backend owns
+	URLPatterns() []*regexp.Regexp
+	// Resolve returns what an owned URL points to
+	Resolve(*url.URL) (*Link, error)
+}
+
+// Owns tells whether given backend owns given URL
+func Owns(b Backend, u *url.URL) bool {
+	if !Has(b, CapabilityLinks) {
+		return false
+	}
+
+	for _, pattern := range b.(Linker).URLPatterns() {
+		if pattern.MatchString(u.String()) {
+			return true
+		}
+	}
+	return false
+}
+
+// ResolveURL returns the slug of the backend owning given URL, among given
+// backends, and what the URL points to
+func ResolveURL(backends map[string]Backend, u *url.URL) (string, *Link, error) {
+	slugs := make([]string, 0, len(backends))
+	for slug := range backends {
+		slugs = append(slugs, slug)
+	}
+	sort.Strings(slugs)
+
+	for _, slug := range slugs {
+		b := backends[slug]
+		if !Owns(b, u) {
+			continue
+		}
+
+		link, err := b.(Linker).Resolve(u)
+		if err != nil {
+			return "", nil, err
+		}
+		return slug, link, nil
+	}
+	return "", nil, fmt.Errorf("%w: %s", ErrUnknownURL, u)
+}
+
+// linkParts are the URLs of the manga and chapter an URL belongs to, page is
+// the URL itself when it points to a page
+type linkParts struct {
+	manga   *url.URL
+	chapter *url.URL
+	page    *url.URL
+}
+
+// matchLink matches given URL against patterns whose named groups "manga"
+// and "chapter" end where the manga and chapter URLs end, and whose "page"
+// group tells the URL is a page
+func matchLink(patterns []*regexp.Regexp, u *url.URL) (*linkParts, error) {
+	raw := u.String()
+	for _, pattern := range patterns {
+		match := pattern.FindStringSubmatchIndex(raw)
+		if match == nil {
+			continue
+		}
+
+		parts := &linkParts{}
+		for i, name := range pattern.SubexpNames() {
+			if len(name) == 0 || match[2*i] < 0 {
+				continue
+			}
+
+			prefix, err := url.Parse(strings.TrimSuffix(raw[:match[2*i+1]], "/"))
+			if err != nil {
+				return nil, err
+			}
+			switch name {
+			case "manga":
+				parts.manga = prefix
+			case "chapter":
+				parts.chapter = prefix
+			case "page":
+				parts.page = u
+			}
+		}
+
+		if parts.manga != nil {
+			return parts, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnknownURL, u)
+}
+
+// rebase moves parts to given base URL scheme and host, so links pasted
+// with "www." or another scheme give the URLs the backend reads
+func (p *linkParts) rebase(base string) error {
+	if len(base) == 0 {
+		return nil
+	}
+	b, err := url.Parse(base)
+	if err != nil {
+		return err
+	}
+
+	rebased := func(u *url.URL) *url.URL {
+		if u == nil {
+			return nil
+		}
+		u = urlCopy(u)
+		u.Scheme, u.Host = b.Scheme, b.Host
+		return u
+	}
+	p.manga, p.chapter, p.page = rebased(p.manga), rebased(p.chapter), rebased(p.page)
+	return nil
+}
+
+// linkManga returns a manga named after its URL, for sites whose manga page
+// does not tell its name
+func linkManga(mangaURL *url.URL) *Manga {
+	slug := path.Base(strings.TrimSuffix(mangaURL.Path, "/"))
+	name := strings.Join(strings.FieldsFunc(slug, func(r rune) bool { return r == '-' || r == '_' }), " ")
+	return &Manga{ID: slug, Name: name, Slug: slug, URL: mangaURL}
+}
+
+// resolveLink completes a Link to given manga with the chapter and page
+// given parts point to, the chapter is looked up in manga chapters
+func resolveLink(b Backend, manga *Manga, parts *linkParts) (*Link, error) {
+	link := &Link{Manga: manga}
+	if parts.chapter == nil {
+		return link, nil
+	}
+
+	chapters, err := b.Chapters(manga)
+	if err != nil {
+		return nil, err
+	}
+	for _, chapter := range chapters {
+		if sameLinkURL(chapter.URL, parts.chapter) {
+			link.Chapter = chapter
+			break
+		}
+	}
+	if link.Chapter == nil {
+		return nil, &client.StatusError{Code: 404, URL: parts.chapter}
+	}
+
+	if parts.page != nil {
+		link.Page = &Page{URL: parts.page}
+	}
+	return link, nil
+}
+
+// sameLinkURL compares URLs ignoring scheme, "www." and a page file like
+// "/1.html" some sites end chapter URLs with
+func sameLinkURL(a *url.URL, b *url.URL) bool {
+	normalise := func(u *url.URL) string {
+		p := strings.TrimSuffix(regexpLinkPageFile.ReplaceAllString(u.Path, ""), "/")
+		return strings.TrimPrefix(u.Host, "www.") + p
+	}
+	return normalise(a) == normalise(b)
+}
@@ -0,0 +1,99 @@
+package backends
+
+import (
+	"errors"
+	"net/url"
+	"regexp"
+	"testing"
+)
+
+func mustParseURL(t *testing.T, raw string) *url.URL {
+	t.Helper()
+	u, err := url.Parse(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u
+}
+
+// urlString returns given URL as a string, empty for nil
+func urlString(u *url.URL) string {
+	if u == nil {
+		return ""
+	}
+	return u.String()
+}
+
+func TestMatchLink(t *testing.T) {
+	tests := []struct {
+		link    string
+		manga   string
+		chapter string
+		page    string
+	}{
+		{"http://mangafox.la/manga/foo/v01/c003/1.html", "http://mangafox.la/manga/foo", "http://mangafox.la/manga/foo/v01/c003", "http://mangafox.la/manga/foo/v01/c003/1.html"},
+		{"http://mangafox.la/manga/foo/c010.5/", "http://mangafox.la/manga/foo", "http://mangafox.la/manga/foo/c010.5", ""},
+		{"https://www.mangafox.la/manga/foo/", "https://www.mangafox.la/manga/foo", "", ""},
+		{"http://mangafox.la/manga/foo?ref=home#top", "http://mangafox.la/manga/foo", "", ""},
+	}
+
+	for _, test := range tests {
+		parts, err := matchLink([]*regexp.Regexp{MangaFoxRegexpLink}, mustParseURL(t, test.link))
+		if err != nil {
+			t.Errorf("%s: %s", test.link, err)
+			continue
+		}
+		got := [3]string{urlString(parts.manga), urlString(parts.chapter), urlString(parts.page)}
+		if want := [3]string{test.manga, test.chapter, test.page}; got != want {
+			t.Errorf("%s: got %q, want %q", test.link, got, want)
+		}
+	}
+
+	for _, link := range []string{"http://mangafox.la/directory/", "http://mangafox.la.example.com/manga/foo", "ftp://mangafox.la/manga/foo"} {
+		_, err := matchLink([]*regexp.Regexp{MangaFoxRegexpLink}, mustParseURL(t, link))
+		if !errors.Is(err, ErrUnknownURL) {
+			t.Errorf("%s: got %v, want ErrUnknownURL", link, err)
+		}
+	}
+}
+
+func TestLinkRebase(t *testing.T) {
+	parts, err := matchLink([]*regexp.Regexp{MangaFoxRegexpLink}, mustParseURL(t, "https://www.mangafox.la/manga/foo/v01/c003/2.html?ref=home"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = parts.rebase(MangaFoxBaseURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := [3]string{urlString(parts.manga), urlString(parts.chapter), urlString(parts.page)}
+	want := [3]string{"http://mangafox.la/manga/foo", "http://mangafox.la/manga/foo/v01/c003", "http://mangafox.la/manga/foo/v01/c003/2.html?ref=home"}
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	err = parts.rebase("")
+	if err != nil || urlString(parts.manga) != want[0] {
+		t.Errorf("empty base moved the manga URL to %s", parts.manga)
+	}
+}
+
+func TestSameLinkURL(t *testing.T) {
+	tests := []struct {
+		a    string
+		b    string
+		want bool
+	}{
+		{"http://mangafox.la/manga/foo/v01/c003/1.html", "https://www.mangafox.la/manga/foo/v01/c003", true},
+		{"http://mangafox.la/manga/foo/v01/c003/", "http://mangafox.la/manga/foo/v01/c003", true},
+		{"http://mangafox.la/manga/foo/v01/c003/1.html", "http://mangafox.la/manga/foo/v01/c004/1.html", false},
+		{"http://mangafox.la/manga/foo/c003", "http://fanfox.net/manga/foo/c003", false},
+	}
+
+	for _, test := range tests {
+		if got := sameLinkURL(mustParseURL(t, test.a), mustParseURL(t, test.b)); got != test.want {
+			t.Errorf("sameLinkURL(%s, %s) = %v, want %v", test.a, test.b, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,293 @@
+package backends
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/toxinu/katago/client"
+)
+
+// LocalImageExtensions are the extensions of files Local reads as pages
+var LocalImageExtensions = map[string]bool{
+	".jpg":  true,
+	".jpeg": true,
+	".png":  true,
+	".gif":  true,
+	".webp": true,
+	".bmp":  true,
+}
+
+// Local is a backend reading mangas already on disk, every folder of Dir is
+// a manga whose chapters are its subfolders and CBZ, ZIP or CBR archives.
+// Pages are file URLs served by client.LocalTransport, only the local backend
+// client reads files.
+type Local struct {
+	Dir string
+}
+
+// Name implements Backend interface
+func (*Local) Name() string {
+	return "Local"
+}
+
+// Search implements Backend interface
+func (b *Local) Search(query *Query) ([]*Manga, error) {
+	files, err := ioutil.ReadDir(b.Dir)
+	if os.IsNotExist(err) {
+		return []*Manga{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	term := strings.ToLower(query.Term)
+	results := make([]*Manga, 0)
+	for _, file := range files {
+		if !file.IsDir() || !strings.Contains(strings.ToLower(file.Name()), term) {
+			continue
+		}
+
+		results = append(results, &Manga{
+			ID:   file.Name(),
+			Name: file.Name(),
+			Slug: file.Name(),
+			URL:  localURL(filepath.Join(b.Dir, file.Name())),
+		})
+	}
+	return results, nil
+}
+
+// URLPatterns implements Linker interface, file URLs under Dir are owned
+func (b *Local) URLPatterns() []*regexp.Regexp {
+	dir, err := filepath.Abs(b.Dir)
+	if err != nil {
+		return nil
+	}
+	return []*regexp.Regexp{regexp.MustCompile("^" + regexp.QuoteMeta(localURL(dir).String()) + "/[^/]")}
+}
+
+// Resolve implements Linker interface, URLs point to a manga folder, a
+// chapter folder or archive, or a page image
+func (b *Local) Resolve(u *url.URL) (*Link, error) {
+	if u.Scheme != "file" {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownURL, u)
+	}
+	name := filepath.Clean(filepath.FromSlash(u.Path))
+
+	dir, err := filepath.Abs(b.Dir)
+	if err != nil {
+		return nil, err
+	}
+	relative, err := filepath.Rel(dir, name)
+	if err != nil || relative == "." || strings.HasPrefix(relative, "..") {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownURL, u)
+	}
+
+	mangaName := strings.Split(filepath.ToSlash(relative), "/")[0]
+	mangaDir := filepath.Join(dir, mangaName)
+	_, err = os.Stat(mangaDir)
+	if os.IsNotExist(err) {
+		return nil, &client.StatusError{Code: 404, URL: u}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	link := &Link{Manga: &Manga{ID: mangaName, Name: mangaName, Slug: mangaName, URL: localURL(mangaDir)}}
+	if name == mangaDir {
+		return link, nil
+	}
+
+	chapters, err := b.Chapters(link.Manga)
+	if err != nil {
+		return nil, err
+	}
+	for _, chapter := range chapters {
+		chapterPath, err := localPath(chapter.URL)
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case chapterPath == mangaDir:
+			// Images directly in manga folder
+			if filepath.Dir(name) != mangaDir || !isLocalImage(name) {
+				continue
+			}
+			link.Page = &Page{URL: localURL(name)}
+		case chapterPath == name:
+		case strings.HasPrefix(name, chapterPath+string(filepath.Separator)) && isLocalImage(name):
+			link.Page = &Page{URL: localURL(name)}
+		default:
+			continue
+		}
+
+		link.Chapter = chapter
+		return link, nil
+	}
+	return nil, &client.StatusError{Code: 404, URL: u}
+}
+
+// Chapters implements Backend interface, images found directly in the manga
+// folder make a chapter named after the manga
+func (b *Local) Chapters(manga *Manga) ([]*Chapter, error) {
+	dir, err := localPath(manga.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, &client.StatusError{Code: 404, URL: manga.URL}
+	}
+	if err != nil {
+		return nil, err
+	}
+	sortFileInfos(files)
+
+	chapters := make([]*Chapter, 0, len(files))
+	hasImages := false
+	for _, file := range files {
+		switch {
+		case file.IsDir():
+			chapters = append(chapters, &Chapter{Name: file.Name(), URL: localURL(filepath.Join(dir, file.Name()))})
+		case client.IsArchive(file.Name()):
+			name := strings.TrimSuffix(file.Name(), filepath.Ext(file.Name()))
+			chapters = append(chapters, &Chapter{Name: name, URL: localURL(filepath.Join(dir, file.Name()))})
+		case isLocalImage(file.Name()):
+			hasImages = true
+		}
+	}
+
+	if hasImages {
+		chapters = append([]*Chapter{{Name: manga.Name, URL: manga.URL}}, chapters...)
+	}
+	return chapters, nil
+}
+
+// Pages implements Backend interface
+func (b *Local) Pages(chapter *Chapter) ([]*Page, error) {
+	name, err := localPath(chapter.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := os.Stat(name)
+	if os.IsNotExist(err) {
+		return nil, &client.StatusError{Code: 404, URL: chapter.URL}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []string
+	if info.IsDir() {
+		files, err := ioutil.ReadDir(name)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			if !file.IsDir() {
+				entries = append(entries, file.Name())
+			}
+		}
+	} else {
+		entries, err = client.ArchiveEntries(name)
+		if err != nil {
+			return nil, &ParseError{Backend: b.Name(), URL: chapter.URL, Err: err}
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return naturalLess(entries[i], entries[j]) })
+
+	pages := make([]*Page, 0, len(entries))
+	for _, entry := range entries {
+		if isLocalImage(entry) {
+			pages = append(pages, &Page{URL: localURL(filepath.Join(name, filepath.FromSlash(entry)))})
+		}
+	}
+	return pages, nil
+}
+
+// PageImageURL implements Backend interface
+func (b *Local) PageImageURL(page *Page) (*url.URL, error) {
+	name, err := localPath(page.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	if archive, _, ok := client.SplitArchivePath(name); ok {
+		name = archive
+	}
+	_, err = os.Stat(name)
+	if os.IsNotExist(err) {
+		return nil, &client.StatusError{Code: 404, URL: page.URL}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return page.URL, nil
+}
+
+func localURL(name string) *url.URL {
+	return &url.URL{Scheme: "file", Path: filepath.ToSlash(name)}
+}
+
+// localPath returns the path of a file URL, other URLs are not found
+func localPath(u *url.URL) (string, error) {
+	if u.Scheme != "file" {
+		return "", &client.StatusError{Code: 404, URL: u}
+	}
+	return filepath.FromSlash(u.Path), nil
+}
+
+func isLocalImage(name string) bool {
+	return LocalImageExtensions[strings.ToLower(filepath.Ext(name))]
+}
+
+func sortFileInfos(files []os.FileInfo) {
+	sort.Slice(files, func(i, j int) bool { return naturalLess(files[i].Name(), files[j].Name()) })
+}
+
+// naturalLess compares strings ignoring case and comparing digit runs by
+// value, so "c2" comes before "c10"
+func naturalLess(a string, b string) bool {
+	ra, rb := []rune(strings.ToLower(a)), []rune(strings.ToLower(b))
+
+	for len(ra) > 0 && len(rb) > 0 {
+		if unicode.IsDigit(ra[0]) && unicode.IsDigit(rb[0]) {
+			na, nb := digitsLength(ra), digitsLength(rb)
+			da := strings.TrimLeft(string(ra[:na]), "0")
+			db := strings.TrimLeft(string(rb[:nb]), "0")
+			if len(da) != len(db) {
+				return len(da) < len(db)
+			}
+			if da != db {
+				return da < db
+			}
+			ra, rb = ra[na:], rb[nb:]
+			continue
+		}
+
+		if ra[0] != rb[0] {
+			return ra[0] < rb[0]
+		}
+		ra, rb = ra[1:], rb[1:]
+	}
+	return len(ra) < len(rb)
+}
+
+func digitsLength(r []rune) int {
+	i := 0
+	for i < len(r) && unicode.IsDigit(r[i]) {
+		i++
+	}
+	return i
+}
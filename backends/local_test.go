@@ -0,0 +1,131 @@
+package backends_test
+
+import (
+	"archive/zip"
+	"errors"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/toxinu/katago/backends"
+	"github.com/toxinu/katago/backends/backendtest"
+	"github.com/toxinu/katago/client"
+)
+
+func TestLocal(t *testing.T) {
+	dir, err := ioutil.TempDir("", "katago-library-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, chapter := range []string{"Chapter 1", "Chapter 2", "Chapter 10"} {
+		chapterDir := filepath.Join(dir, "Berserk", chapter)
+		err = os.MkdirAll(chapterDir, 0755)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, page := range []string{"001.png", "002.png"} {
+			err = ioutil.WriteFile(filepath.Join(chapterDir, page), backendtest.Image(chapter+page), 0644)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	c := client.NewClient().WithLocalFiles()
+	c.Limiter = nil
+
+	suite := &backendtest.Suite{Backend: &backends.Local{Dir: dir}, Term: "berserk", Client: c}
+	err = suite.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// localLibrary creates a library with a Berserk manga holding a chapter
+// folder and a chapter archive
+func localLibrary(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "katago-library-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	chapterDir := filepath.Join(dir, "Berserk", "Chapter 1")
+	err = os.MkdirAll(chapterDir, 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ioutil.WriteFile(filepath.Join(chapterDir, "001.png"), backendtest.Image("001.png"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := os.Create(filepath.Join(dir, "Berserk", "Chapter 2.cbz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	w := zip.NewWriter(file)
+	f, err := w.Create("001.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = f.Write(backendtest.Image("002.png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = w.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestLocalResolve(t *testing.T) {
+	dir := localLibrary(t)
+	b := &backends.Local{Dir: dir}
+	fileURL := func(name string) string {
+		return (&url.URL{Scheme: "file", Path: filepath.ToSlash(filepath.Join(dir, filepath.FromSlash(name)))}).String()
+	}
+
+	tests := []struct {
+		name    string
+		chapter string
+		page    string
+	}{
+		{"Berserk", "", ""},
+		{"Berserk/Chapter 1", "Chapter 1", ""},
+		{"Berserk/Chapter 1/001.png", "Chapter 1", "Berserk/Chapter 1/001.png"},
+		{"Berserk/Chapter 2.cbz", "Chapter 2", ""},
+		{"Berserk/Chapter 2.cbz/001.png", "Chapter 2", "Berserk/Chapter 2.cbz/001.png"},
+	}
+	for _, test := range tests {
+		link, err := b.Resolve(mustParse(t, fileURL(test.name)))
+		if err != nil {
+			t.Errorf("%s: %s", test.name, err)
+			continue
+		}
+		if len(test.page) > 0 {
+			test.page = fileURL(test.page)
+		}
+		expectLink(t, link, "Berserk", test.chapter, test.page)
+	}
+
+	for _, name := range []string{"Vagabond", "Berserk/Chapter 3"} {
+		_, err := b.Resolve(mustParse(t, fileURL(name)))
+		if !errors.Is(err, client.ErrNotFound) {
+			t.Errorf("%s: got %v, want ErrNotFound", name, err)
+		}
+	}
+
+	for _, link := range []string{fileURL(".."), "http://mangafox.la/manga/berserk/"} {
+		_, err := b.Resolve(mustParse(t, link))
+		if !errors.Is(err, backends.ErrUnknownURL) {
+			t.Errorf("%s: got %v, want ErrUnknownURL", link, err)
+		}
+	}
+}
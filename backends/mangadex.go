@@ -0,0 +1,805 @@
+package backends
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/toxinu/katago/client"
+)
+
+const (
+	// MangaDexAPIURL is base URL of MangaDex API
+	MangaDexAPIURL = "https://api.mangadex.org"
+	// MangaDexCoversURL is base URL of MangaDex cover art
+	MangaDexCoversURL = "https://uploads.mangadex.org/covers"
+	// MangaDexSearchLimit is the number of search results asked for
+	MangaDexSearchLimit = 100
+	// MangaDexLatestLimit is the number of chapters asked for per latest
+	// updates page
+	MangaDexLatestLimit = 100
+	// MangaDexFeedLimit is the number of chapters asked for per feed page
+	MangaDexFeedLimit = 500
+	// MangaDexAtHomeTTL is how long an at-home server answer is reused,
+	// MangaDex at-home URLs expire after 15 minutes
+	MangaDexAtHomeTTL = 10 * time.Minute
+)
+
+var (
+	// MangaDexLimit is MangaDex default politeness rules
+	MangaDexLimit = client.Limit{Rate: 4, Burst: 4, MaxConnections: 4}
+
+	regexpMangaDexSiteLink = regexp.MustCompile(`^https?://(?:www\.)?mangadex\.org/(title|manga|chapter)/([^/?#]+)(?:/(\d+))?`)
+)
+
+// MangaDex is a backend for MangaDex API and sites sharing its API
+type MangaDex struct {
+	Client *client.Client
+	// APIURL defaults to MangaDexAPIURL
+	APIURL string
+	// CoversURL defaults to MangaDexCoversURL
+	CoversURL string
+	// Languages are chapter languages by order of preference, "en" when empty
+	Languages []string
+	// DataSaver downloads compressed images
+	DataSaver bool
+
+	mutex  sync.Mutex
+	atHome map[string]*mangaDexAtHome
+	tags   map[string]string
+}
+
+// mangaDexSorts maps Sort constants to MangaDex search orders
+var mangaDexSorts = map[string][2]string{
+	SortRelevance:  {"relevance", "desc"},
+	SortPopularity: {"followedCount", "desc"},
+	SortLatest:     {"latestUploadedChapter", "desc"},
+	SortTitle:      {"title", "asc"},
+}
+
+type mangaDexEntityList struct {
+	Data []struct {
+		ID         string `json:"id"`
+		Attributes struct {
+			Name interface{} `json:"name"`
+		} `json:"attributes"`
+	} `json:"data"`
+}
+
+type mangaDexMangaList struct {
+	Data []*mangaDexManga `json:"data"`
+}
+
+type mangaDexManga struct {
+	ID         string `json:"id"`
+	Attributes struct {
+		Title map[string]string `json:"title"`
+		Tags  []struct {
+			Attributes struct {
+				Name  map[string]string `json:"name"`
+				Group string            `json:"group"`
+			} `json:"attributes"`
+		} `json:"tags"`
+	} `json:"attributes"`
+	Relationships []struct {
+		Type       string `json:"type"`
+		Attributes struct {
+			Name     string `json:"name"`
+			FileName string `json:"fileName"`
+		} `json:"attributes"`
+	} `json:"relationships"`
+}
+
+type mangaDexMangaEntity struct {
+	Data *mangaDexManga `json:"data"`
+}
+
+type mangaDexChapterList struct {
+	Data  []*mangaDexChapter `json:"data"`
+	Total int                `json:"total"`
+}
+
+type mangaDexChapterEntity struct {
+	Data *mangaDexChapter `json:"data"`
+}
+
+type mangaDexChapter struct {
+	ID         string `json:"id"`
+	Attributes struct {
+		Volume             *string `json:"volume"`
+		Chapter            *string `json:"chapter"`
+		Title              *string `json:"title"`
+		TranslatedLanguage string  `json:"translatedLanguage"`
+		ExternalURL        *string `json:"externalUrl"`
+		Pages              int     `json:"pages"`
+		ReadableAt         string  `json:"readableAt"`
+	} `json:"attributes"`
+	Relationships []struct {
+		ID         string `json:"id"`
+		Type       string `json:"type"`
+		Attributes struct {
+			Title map[string]string `json:"title"`
+		} `json:"attributes"`
+	} `json:"relationships"`
+}
+
+type mangaDexAtHome struct {
+	BaseURL string `json:"baseUrl"`
+	Chapter struct {
+		Hash      string   `json:"hash"`
+		Data      []string `json:"data"`
+		DataSaver []string `json:"dataSaver"`
+	} `json:"chapter"`
+
+	fetched time.Time
+}
+
+// Name implements Backend interface
+func (*MangaDex) Name() string {
+	return "MangaDex"
+}
+
+func (b *MangaDex) apiURL(format string, args ...interface{}) (*url.URL, error) {
+	base := b.APIURL
+	if len(base) == 0 {
+		base = MangaDexAPIURL
+	}
+	return url.Parse(strings.TrimSuffix(base, "/") + fmt.Sprintf(format, args...))
+}
+
+// ChapterLanguages implements Multilingual interface
+func (b *MangaDex) ChapterLanguages() []string {
+	if len(b.Languages) == 0 {
+		return []string{"en"}
+	}
+	return b.Languages
+}
+
+// get decodes the JSON answer of an API URL requested with given client
+func (b *MangaDex) get(c *client.Client, u *url.URL, value interface{}) error {
+	resp, err := c.Get(u, []int{200})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	err = json.NewDecoder(resp.Body).Decode(value)
+	if err != nil {
+		return &ParseError{Backend: b.Name(), URL: u, Err: err}
+	}
+	return nil
+}
+
+// Filters implements Filterer interface
+func (*MangaDex) Filters() Filters {
+	return Filters{
+		Paging:        true,
+		Sorts:         []string{SortRelevance, SortPopularity, SortLatest, SortTitle},
+		IncludeGenres: true,
+		ExcludeGenres: true,
+		Statuses:      []string{StatusOngoing, StatusCompleted},
+		Author:        true,
+	}
+}
+
+// Search implements Backend interface
+func (b *MangaDex) Search(query *Query) ([]*Manga, error) {
+	c := b.Client.For(client.RequestSearch).WithContext(query.Context())
+
+	searchURL, err := b.apiURL("/manga")
+	if err != nil {
+		return nil, err
+	}
+
+	values := url.Values{}
+	if len(query.Term) > 0 {
+		values.Set("title", query.Term)
+	}
+	values.Set("limit", strconv.Itoa(MangaDexSearchLimit))
+	if query.Page > 1 {
+		values.Set("offset", strconv.Itoa((query.Page-1)*MangaDexSearchLimit))
+	}
+	values.Add("includes[]", "author")
+	for _, language := range b.ChapterLanguages() {
+		values.Add("availableTranslatedLanguage[]", language)
+	}
+
+	sortBy := query.Sort
+	if len(sortBy) == 0 && len(query.Term) == 0 {
+		sortBy = SortPopularity
+	}
+	if order, ok := mangaDexSorts[sortBy]; ok {
+		values.Set(fmt.Sprintf("order[%s]", order[0]), order[1])
+	}
+
+	for _, genres := range []struct {
+		key    string
+		genres []string
+	}{{"includedTags[]", query.IncludeGenres}, {"excludedTags[]", query.ExcludeGenres}} {
+		for _, genre := range genres.genres {
+			id, err := b.tag(c, genre)
+			if err != nil {
+				return nil, err
+			}
+			values.Add(genres.key, id)
+		}
+	}
+
+	if len(query.Status) > 0 {
+		values.Add("status[]", query.Status)
+	}
+
+	if len(query.Author) > 0 {
+		authors, err := b.authors(c, query.Author)
+		if err != nil {
+			return nil, err
+		}
+		if len(authors) == 0 {
+			return []*Manga{}, nil
+		}
+		for _, author := range authors {
+			values.Add("authorOrArtist", author)
+		}
+	}
+
+	searchURL.RawQuery = values.Encode()
+
+	list := &mangaDexMangaList{}
+	err = b.get(c, searchURL, list)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*Manga, 0, len(list.Data))
+	for _, entity := range list.Data {
+		manga, err := b.manga(entity)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, manga)
+	}
+	return results, nil
+}
+
+func (b *MangaDex) manga(entity *mangaDexManga) (*Manga, error) {
+	mangaURL, err := b.apiURL("/manga/%s", entity.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Manga{
+		ID:     entity.ID,
+		Name:   b.localized(entity.Attributes.Title),
+		Slug:   entity.ID,
+		Author: entity.author(),
+		Genre:  b.genres(entity),
+		URL:    mangaURL,
+	}, nil
+}
+
+// mangaByID fetches a manga with its author
+func (b *MangaDex) mangaByID(id string) (*Manga, error) {
+	mangaURL, err := b.apiURL("/manga/%s", id)
+	if err != nil {
+		return nil, err
+	}
+	mangaURL.RawQuery = url.Values{"includes[]": {"author"}}.Encode()
+
+	entity := &mangaDexMangaEntity{}
+	err = b.get(b.Client.For(client.RequestChapters), mangaURL, entity)
+	if err != nil {
+		return nil, err
+	}
+	if entity.Data == nil {
+		return nil, &ParseError{Backend: b.Name(), URL: mangaURL, Err: errors.New("missing manga data")}
+	}
+	return b.manga(entity.Data)
+}
+
+// URLPatterns implements Linker interface, site and API manga and chapter
+// URLs are owned
+func (b *MangaDex) URLPatterns() []*regexp.Regexp {
+	patterns := []*regexp.Regexp{regexpMangaDexSiteLink}
+	if apiURL, err := b.apiURL(""); err == nil {
+		patterns = append(patterns, regexp.MustCompile("^"+regexp.QuoteMeta(apiURL.String())+`/(manga|chapter)/([^/?#]+)`))
+	}
+	return patterns
+}
+
+// Resolve implements Linker interface, site chapter URLs may end with a
+// page number and API chapter URLs with a page query parameter
+func (b *MangaDex) Resolve(u *url.URL) (*Link, error) {
+	var match []string
+	for _, pattern := range b.URLPatterns() {
+		if match = pattern.FindStringSubmatch(u.String()); match != nil {
+			break
+		}
+	}
+	if match == nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownURL, u)
+	}
+
+	kind, id := match[1], match[2]
+	if kind != "chapter" {
+		manga, err := b.mangaByID(id)
+		if err != nil {
+			return nil, err
+		}
+		return &Link{Manga: manga}, nil
+	}
+
+	chapterURL, err := b.apiURL("/chapter/%s", id)
+	if err != nil {
+		return nil, err
+	}
+
+	entity := &mangaDexChapterEntity{}
+	err = b.get(b.Client.For(client.RequestChapters), chapterURL, entity)
+	if err != nil {
+		return nil, err
+	}
+	if entity.Data == nil {
+		return nil, &ParseError{Backend: b.Name(), URL: chapterURL, Err: errors.New("missing chapter data")}
+	}
+
+	var mangaID string
+	for _, relationship := range entity.Data.Relationships {
+		if relationship.Type == "manga" {
+			mangaID = relationship.ID
+		}
+	}
+	if len(mangaID) == 0 {
+		return nil, &ParseError{Backend: b.Name(), URL: chapterURL, Err: errors.New("missing chapter manga")}
+	}
+
+	manga, err := b.mangaByID(mangaID)
+	if err != nil {
+		return nil, err
+	}
+	link := &Link{Manga: manga, Chapter: &Chapter{Name: entity.Data.name(), URL: chapterURL}}
+
+	switch {
+	case len(match) > 3 && len(match[3]) > 0:
+		number, _ := strconv.Atoi(match[3])
+		pages, err := b.Pages(link.Chapter)
+		if err != nil {
+			return nil, err
+		}
+		if number < 1 || number > len(pages) {
+			return nil, &client.StatusError{Code: 404, URL: u}
+		}
+		link.Page = pages[number-1]
+	case len(u.Query().Get("page")) > 0:
+		link.Page = &Page{URL: u}
+	}
+	return link, nil
+}
+
+// tag returns the ID of given genre, tags are looked up with given client
+func (b *MangaDex) tag(c *client.Client, genre string) (string, error) {
+	b.mutex.Lock()
+	tags := b.tags
+	b.mutex.Unlock()
+
+	if tags == nil {
+		tagsURL, err := b.apiURL("/manga/tag")
+		if err != nil {
+			return "", err
+		}
+
+		list := &mangaDexEntityList{}
+		err = b.get(c, tagsURL, list)
+		if err != nil {
+			return "", err
+		}
+
+		tags = map[string]string{}
+		for _, entity := range list.Data {
+			names, _ := entity.Attributes.Name.(map[string]interface{})
+			for _, name := range names {
+				if name, ok := name.(string); ok {
+					tags[strings.ToLower(name)] = entity.ID
+				}
+			}
+		}
+
+		b.mutex.Lock()
+		b.tags = tags
+		b.mutex.Unlock()
+	}
+
+	id, ok := tags[strings.ToLower(genre)]
+	if !ok {
+		return "", fmt.Errorf("unknown %s genre '%s'", b.Name(), genre)
+	}
+	return id, nil
+}
+
+// authors returns the IDs of authors and artists matching given name
+func (b *MangaDex) authors(c *client.Client, name string) ([]string, error) {
+	authorsURL, err := b.apiURL("/author")
+	if err != nil {
+		return nil, err
+	}
+	authorsURL.RawQuery = url.Values{"name": {name}, "limit": {"10"}}.Encode()
+
+	list := &mangaDexEntityList{}
+	err = b.get(c, authorsURL, list)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(list.Data))
+	for _, entity := range list.Data {
+		ids = append(ids, entity.ID)
+	}
+	return ids, nil
+}
+
+// localized returns the most preferred translation of a text
+func (b *MangaDex) localized(texts map[string]string) string {
+	for _, language := range append(b.ChapterLanguages(), "en") {
+		if text, ok := texts[language]; ok {
+			return text
+		}
+	}
+
+	languages := make([]string, 0, len(texts))
+	for language := range texts {
+		languages = append(languages, language)
+	}
+	sort.Strings(languages)
+	if len(languages) == 0 {
+		return ""
+	}
+	return texts[languages[0]]
+}
+
+func (b *MangaDex) genres(entity *mangaDexManga) string {
+	genres := make([]string, 0, len(entity.Attributes.Tags))
+	for _, tag := range entity.Attributes.Tags {
+		if tag.Attributes.Group == "genre" {
+			genres = append(genres, b.localized(tag.Attributes.Name))
+		}
+	}
+	return strings.Join(genres, ", ")
+}
+
+func (e *mangaDexManga) author() string {
+	for _, relationship := range e.Relationships {
+		if relationship.Type == "author" {
+			return relationship.Attributes.Name
+		}
+	}
+	return ""
+}
+
+// CoverURL implements CoverProvider interface
+func (b *MangaDex) CoverURL(manga *Manga) (*url.URL, error) {
+	mangaURL := urlCopy(manga.URL)
+	mangaURL.RawQuery = url.Values{"includes[]": {"cover_art"}}.Encode()
+
+	entity := &mangaDexMangaEntity{}
+	err := b.get(b.Client.For(client.RequestChapters), mangaURL, entity)
+	if err != nil {
+		return nil, err
+	}
+	if entity.Data == nil {
+		return nil, &ParseError{Backend: b.Name(), URL: mangaURL, Err: errors.New("missing manga data")}
+	}
+
+	for _, relationship := range entity.Data.Relationships {
+		if relationship.Type == "cover_art" && len(relationship.Attributes.FileName) > 0 {
+			base := b.CoversURL
+			if len(base) == 0 {
+				base = MangaDexCoversURL
+			}
+			return url.Parse(fmt.Sprintf("%s/%s/%s", strings.TrimSuffix(base, "/"), entity.Data.ID, relationship.Attributes.FileName))
+		}
+	}
+	return nil, &client.StatusError{Code: 404, URL: mangaURL}
+}
+
+// Chapters implements Backend interface, when a chapter is translated in
+// several languages the most preferred one is kept
+func (b *MangaDex) Chapters(manga *Manga) ([]*Chapter, error) {
+	entities := make([]*mangaDexChapter, 0)
+	for offset := 0; ; {
+		feedURL := urlCopy(manga.URL)
+		feedURL.Path = strings.TrimSuffix(feedURL.Path, "/") + "/feed"
+
+		query := url.Values{}
+		query.Set("limit", strconv.Itoa(MangaDexFeedLimit))
+		query.Set("offset", strconv.Itoa(offset))
+		query.Set("order[volume]", "asc")
+		query.Set("order[chapter]", "asc")
+		for _, language := range b.ChapterLanguages() {
+			query.Add("translatedLanguage[]", language)
+		}
+		feedURL.RawQuery = query.Encode()
+
+		list := &mangaDexChapterList{}
+		err := b.get(b.Client.For(client.RequestChapters), feedURL, list)
+		if err != nil {
+			return nil, err
+		}
+
+		entities = append(entities, list.Data...)
+		offset += len(list.Data)
+		if len(list.Data) == 0 || offset >= list.Total {
+			break
+		}
+	}
+
+	preference := map[string]int{}
+	for index, language := range b.ChapterLanguages() {
+		preference[language] = index
+	}
+
+	// kept holds the index in entities of the chapter kept for each volume
+	// and number, some series number chapters from 1 in every volume
+	kept := map[string]int{}
+	order := make([]string, 0, len(entities))
+	for index, entity := range entities {
+		attributes := entity.Attributes
+		if attributes.ExternalURL != nil || attributes.Pages == 0 {
+			continue
+		}
+
+		key := "id:" + entity.ID
+		if attributes.Chapter != nil && len(*attributes.Chapter) > 0 {
+			key = *attributes.Chapter
+			if attributes.Volume != nil && len(*attributes.Volume) > 0 {
+				key = *attributes.Volume + " " + key
+			}
+		}
+
+		previous, ok := kept[key]
+		if !ok {
+			order = append(order, key)
+		}
+		if !ok || preference[attributes.TranslatedLanguage] < preference[entities[previous].Attributes.TranslatedLanguage] {
+			kept[key] = index
+		}
+	}
+
+	sorted := &chaptersByNumber{
+		chapters: make([]*Chapter, 0, len(order)),
+		volumes:  make([]float64, 0, len(order)),
+		numbers:  make([]float64, 0, len(order)),
+	}
+	for _, key := range order {
+		entity := entities[kept[key]]
+
+		chapterURL, err := b.apiURL("/chapter/%s", entity.ID)
+		if err != nil {
+			return nil, err
+		}
+		sorted.chapters = append(sorted.chapters, &Chapter{Name: entity.name(), URL: chapterURL})
+
+		volume, number := entity.numbers()
+		sorted.volumes = append(sorted.volumes, volume)
+		sorted.numbers = append(sorted.numbers, number)
+	}
+
+	sort.Stable(sorted)
+	return sorted.chapters, nil
+}
+
+// Latest implements Updater interface, chapters are the ones readable last
+// in preferred languages
+func (b *MangaDex) Latest(page int) ([]*Update, error) {
+	latestURL, err := b.apiURL("/chapter")
+	if err != nil {
+		return nil, err
+	}
+
+	values := url.Values{}
+	values.Set("limit", strconv.Itoa(MangaDexLatestLimit))
+	if page > 1 {
+		values.Set("offset", strconv.Itoa((page-1)*MangaDexLatestLimit))
+	}
+	values.Set("order[readableAt]", "desc")
+	values.Add("includes[]", "manga")
+	for _, language := range b.ChapterLanguages() {
+		values.Add("translatedLanguage[]", language)
+	}
+	latestURL.RawQuery = values.Encode()
+
+	list := &mangaDexChapterList{}
+	err = b.get(b.Client.For(client.RequestLatest), latestURL, list)
+	if err != nil {
+		return nil, err
+	}
+
+	updates := make([]*Update, 0, len(list.Data))
+	for _, entity := range list.Data {
+		if entity.Attributes.ExternalURL != nil || entity.Attributes.Pages == 0 {
+			continue
+		}
+
+		var manga *Manga
+		for _, relationship := range entity.Relationships {
+			if relationship.Type != "manga" {
+				continue
+			}
+			mangaURL, err := b.apiURL("/manga/%s", relationship.ID)
+			if err != nil {
+				return nil, err
+			}
+			manga = &Manga{
+				ID:   relationship.ID,
+				Name: b.localized(relationship.Attributes.Title),
+				Slug: relationship.ID,
+				URL:  mangaURL,
+			}
+		}
+		if manga == nil {
+			return nil, &ParseError{Backend: b.Name(), URL: latestURL, Err: errors.New("missing chapter manga")}
+		}
+
+		chapterURL, err := b.apiURL("/chapter/%s", entity.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		update := &Update{Manga: manga, Chapter: &Chapter{Name: entity.name(), URL: chapterURL}}
+		if date, err := time.Parse(time.RFC3339, entity.Attributes.ReadableAt); err == nil {
+			update.Date = date
+		}
+		updates = append(updates, update)
+	}
+	return updates, nil
+}
+
+// chaptersByNumber sorts chapters by volume then number
+type chaptersByNumber struct {
+	chapters []*Chapter
+	volumes  []float64
+	numbers  []float64
+}
+
+func (c *chaptersByNumber) Len() int { return len(c.chapters) }
+func (c *chaptersByNumber) Less(i, j int) bool {
+	if c.volumes[i] != c.volumes[j] {
+		return c.volumes[i] < c.volumes[j]
+	}
+	return c.numbers[i] < c.numbers[j]
+}
+func (c *chaptersByNumber) Swap(i, j int) {
+	c.chapters[i], c.chapters[j] = c.chapters[j], c.chapters[i]
+	c.volumes[i], c.volumes[j] = c.volumes[j], c.volumes[i]
+	c.numbers[i], c.numbers[j] = c.numbers[j], c.numbers[i]
+}
+
+// numbers returns chapter volume and number to sort it, chapters without
+// number come first and numbered chapters not in a volume yet come last
+func (e *mangaDexChapter) numbers() (float64, float64) {
+	attributes := e.Attributes
+	if attributes.Chapter == nil || len(*attributes.Chapter) == 0 {
+		return math.Inf(-1), 0
+	}
+
+	number, _ := strconv.ParseFloat(*attributes.Chapter, 64)
+	if attributes.Volume == nil || len(*attributes.Volume) == 0 {
+		return math.Inf(1), number
+	}
+	volume, err := strconv.ParseFloat(*attributes.Volume, 64)
+	if err != nil {
+		return math.Inf(1), number
+	}
+	return volume, number
+}
+
+func (e *mangaDexChapter) name() string {
+	attributes := e.Attributes
+
+	parts := make([]string, 0, 4)
+	if attributes.Volume != nil && len(*attributes.Volume) > 0 {
+		parts = append(parts, "Vol. "+*attributes.Volume)
+	}
+	if attributes.Chapter != nil && len(*attributes.Chapter) > 0 {
+		parts = append(parts, "Ch. "+*attributes.Chapter)
+	}
+	if len(parts) == 0 {
+		parts = append(parts, "Oneshot")
+	}
+	if attributes.Title != nil && len(*attributes.Title) > 0 {
+		parts = append(parts, "-", *attributes.Title)
+	}
+	return strings.Join(parts, " ")
+}
+
+// atHomeServer returns the at-home server answer of a chapter, reused for
+// MangaDexAtHomeTTL
+func (b *MangaDex) atHomeServer(chapterID string) (*mangaDexAtHome, error) {
+	b.mutex.Lock()
+	atHome, ok := b.atHome[chapterID]
+	b.mutex.Unlock()
+	if ok && time.Since(atHome.fetched) < MangaDexAtHomeTTL {
+		return atHome, nil
+	}
+
+	atHomeURL, err := b.apiURL("/at-home/server/%s", chapterID)
+	if err != nil {
+		return nil, err
+	}
+
+	atHome = &mangaDexAtHome{}
+	err = b.get(b.Client.For(client.RequestImage), atHomeURL, atHome)
+	if err != nil {
+		return nil, err
+	}
+	atHome.fetched = time.Now()
+
+	b.mutex.Lock()
+	if b.atHome == nil {
+		b.atHome = map[string]*mangaDexAtHome{}
+	}
+	b.atHome[chapterID] = atHome
+	b.mutex.Unlock()
+
+	return atHome, nil
+}
+
+// Expire implements Expirer interface, at-home servers answering 403 or
+// 404 before MangaDexAtHomeTTL are asked for another one
+func (b *MangaDex) Expire(page *Page) {
+	b.mutex.Lock()
+	delete(b.atHome, path.Base(page.URL.Path))
+	b.mutex.Unlock()
+}
+
+func (a *mangaDexAtHome) files(dataSaver bool) []string {
+	if dataSaver {
+		return a.Chapter.DataSaver
+	}
+	return a.Chapter.Data
+}
+
+// Pages implements Backend interface, page URLs are the chapter URL with a
+// page query parameter naming the page file
+func (b *MangaDex) Pages(chapter *Chapter) ([]*Page, error) {
+	atHome, err := b.atHomeServer(path.Base(chapter.URL.Path))
+	if err != nil {
+		return nil, err
+	}
+
+	files := atHome.files(b.DataSaver)
+	pages := make([]*Page, 0, len(files))
+	for _, file := range files {
+		pageURL := urlCopy(chapter.URL)
+		pageURL.RawQuery = url.Values{"page": {file}}.Encode()
+		pages = append(pages, &Page{URL: pageURL})
+	}
+	return pages, nil
+}
+
+// PageImageURL implements Backend interface, image URLs are built from the
+// at-home server answer, asked for again once expired
+func (b *MangaDex) PageImageURL(page *Page) (*url.URL, error) {
+	file := page.URL.Query().Get("page")
+	if len(file) == 0 {
+		return nil, &client.StatusError{Code: 404, URL: page.URL}
+	}
+
+	atHome, err := b.atHomeServer(path.Base(page.URL.Path))
+	if err != nil {
+		return nil, err
+	}
+
+	quality := "data"
+	if b.DataSaver {
+		quality = "data-saver"
+	}
+	return url.Parse(fmt.Sprintf("%s/%s/%s/%s", strings.TrimSuffix(atHome.BaseURL, "/"), quality, atHome.Chapter.Hash, url.PathEscape(file)))
+}
@@ -0,0 +1,135 @@
+package backends_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/toxinu/katago/backends"
+	"github.com/toxinu/katago/backends/backendtest"
+	"github.com/toxinu/katago/client"
+)
+
+func TestMangaDexCassette(t *testing.T) {
+	server := replay(t, "testdata/mangadex.json")
+
+	walk, err := backendtest.Browse(&backends.MangaDex{Client: server.Client(), Languages: []string{"en"}}, "berserk")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectWalk(t, walk, 2, "Ch. 1", "https://api.mangadex.org/chapter/manga-1-en-1", 3,
+		"https://uploads.mangadex.org/data/manga-1-en-1/1.png")
+	if len(walk.Chapters) != 3 {
+		t.Errorf("got %d chapters, want 3", len(walk.Chapters))
+	}
+}
+
+func TestMangaDex(t *testing.T) {
+	server := replay(t, "testdata/mangadex.json")
+
+	suite := &backendtest.Suite{
+		Backend: &backends.MangaDex{Client: server.Client(), Languages: []string{"en"}},
+		Term:    "berserk",
+		Client:  server.Client(),
+	}
+	err := suite.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMangaDexChaptersOfVolumes(t *testing.T) {
+	images := backendtest.NewImageServer()
+	defer images.Close()
+	server := backendtest.NewMangaDexServer(images)
+	defer server.Close()
+
+	id := server.AddManga("Berserk", "Miura Kentarou", 4, 1, "en", "fr")
+	server.SetVolumes(id, 2)
+
+	b := &backends.MangaDex{Client: server.Client(), Languages: []string{"fr", "en"}}
+	results, err := b.Search(backends.NewQuery("berserk"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	chapters, err := b.Chapters(results[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"Vol. 1 Ch. 1", "Vol. 1 Ch. 2", "Vol. 2 Ch. 1", "Vol. 2 Ch. 2"}
+	if len(chapters) != len(want) {
+		t.Fatalf("got %d chapters, want %d", len(chapters), len(want))
+	}
+	for index, chapter := range chapters {
+		if chapter.Name != want[index] {
+			t.Errorf("got chapter %d %q, want %q", index, chapter.Name, want[index])
+		}
+		if !strings.Contains(chapter.URL.Path, "-fr-") {
+			t.Errorf("got chapter %d %s, want the preferred language", index, chapter.URL)
+		}
+	}
+}
+
+func TestMangaDexLatest(t *testing.T) {
+	images := backendtest.NewImageServer()
+	defer images.Close()
+	server := backendtest.NewMangaDexServer(images)
+	defer server.Close()
+
+	server.AddManga("Berserk", "Miura Kentarou", 2, 1, "en")
+	server.AddManga("Vagabond", "Inoue Takehiko", 1, 1, "en", "fr")
+
+	b := &backends.MangaDex{Client: server.Client(), Languages: []string{"en"}}
+	updates, err := b.Latest(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"Vagabond Ch. 1", "Berserk Ch. 2", "Berserk Ch. 1"}
+	if len(updates) != len(want) {
+		t.Fatalf("got %d updates, want %d", len(updates), len(want))
+	}
+	for index, update := range updates {
+		if got := update.Manga.Name + " " + update.Chapter.Name; got != want[index] {
+			t.Errorf("got update %d %q, want %q", index, got, want[index])
+		}
+	}
+}
+
+func TestMangaDexResolve(t *testing.T) {
+	images := backendtest.NewImageServer()
+	defer images.Close()
+	server := backendtest.NewMangaDexServer(images)
+	defer server.Close()
+	server.AddManga("Berserk", "Miura Kentarou", 2, 3, "en")
+
+	b := &backends.MangaDex{Client: server.Client(), Languages: []string{"en"}}
+	tests := []struct {
+		link    string
+		chapter string
+		page    string
+	}{
+		{"https://mangadex.org/title/manga-1/berserk", "", ""},
+		{"https://api.mangadex.org/manga/manga-1", "", ""},
+		{"https://www.mangadex.org/chapter/manga-1-en-2", "Ch. 2", ""},
+		{"https://mangadex.org/chapter/manga-1-en-2/3", "Ch. 2", "https://api.mangadex.org/chapter/manga-1-en-2?page=3.png"},
+		{"https://api.mangadex.org/chapter/manga-1-en-1?page=2.png", "Ch. 1", "https://api.mangadex.org/chapter/manga-1-en-1?page=2.png"},
+	}
+	for _, test := range tests {
+		link, err := b.Resolve(mustParse(t, test.link))
+		if err != nil {
+			t.Errorf("%s: %s", test.link, err)
+			continue
+		}
+		expectLink(t, link, "Berserk", test.chapter, test.page)
+	}
+
+	for _, link := range []string{"https://mangadex.org/chapter/manga-1-en-2/4", "https://mangadex.org/title/manga-2"} {
+		_, err := b.Resolve(mustParse(t, link))
+		if !errors.Is(err, client.ErrNotFound) {
+			t.Errorf("%s: got %v, want ErrNotFound", link, err)
+		}
+	}
+}
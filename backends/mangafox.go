@@ -2,6 +2,7 @@ package backends
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
@@ -30,8 +31,12 @@ const (
 )
 
 var (
+	// MangaFoxLimit is MangaFox default politeness rules
+	MangaFoxLimit = client.Limit{Rate: 2, Burst: 5, MaxConnections: 4}
 	// MangaFoxRegexpPageBaseURLPath is page URL regexp
 	MangaFoxRegexpPageBaseURLPath = regexp.MustCompile("/?(\\d+\\.html)?$")
+	// MangaFoxRegexpLink matches manga, chapter and page URLs
+	MangaFoxRegexpLink = regexp.MustCompile(`^(?P<manga>https?://(?:www\.)?mangafox\.la/manga/[^/?#]+)/?(?:(?P<chapter>(?:v[^/]+/)?c[\d.]+)/?(?P<page>\d+\.html)?)?(?:[?#].*)?$`)
 )
 
 // MangaFox is MangaFox backend
@@ -45,7 +50,7 @@ func (*MangaFox) Name() string {
 }
 
 // Search implements Backend interface
-func (b *MangaFox) Search(term string) ([]*Manga, error) {
+func (b *MangaFox) Search(query *Query) ([]*Manga, error) {
 	var (
 		err             error
 		body            []byte
@@ -53,17 +58,18 @@ func (b *MangaFox) Search(term string) ([]*Manga, error) {
 		responseResults [][]string
 		results         []*Manga
 		manga           *Manga
-		url             *url.URL
+		u               *url.URL
 	)
-	url, err = url.Parse(fmt.Sprintf("%s/ajax/search.php?term=%s", MangaFoxBaseURL, term))
+	u, err = url.Parse(fmt.Sprintf("%s/ajax/search.php?term=%s", MangaFoxBaseURL, url.QueryEscape(query.Term)))
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err = b.Client.Get(url, []int{200})
+	resp, err = b.Client.For(client.RequestSearch).WithContext(query.Context()).Get(u, []int{200})
 	if err != nil {
 		return nil, err
 	}
+	defer resp.Body.Close()
 
 	body, err = ioutil.ReadAll(resp.Body)
 	if err != nil {
@@ -74,11 +80,15 @@ func (b *MangaFox) Search(term string) ([]*Manga, error) {
 
 	err = json.Unmarshal(body, &responseResults)
 	if err != nil {
-		return nil, err
+		return nil, &ParseError{Backend: b.Name(), URL: resp.Request.URL, Err: err}
 	}
 
 	for i := 0; i < len(responseResults); i++ {
-		url, err = url.Parse(fmt.Sprintf("%s/manga/%s", MangaFoxBaseURL, responseResults[i][2]))
+		if len(responseResults[i]) < 5 {
+			return nil, &ParseError{Backend: b.Name(), URL: resp.Request.URL, Err: errors.New("unexpected search result format")}
+		}
+
+		u, err = url.Parse(fmt.Sprintf("%s/manga/%s", MangaFoxBaseURL, responseResults[i][2]))
 		if err != nil {
 			return nil, err
 		}
@@ -88,16 +98,45 @@ func (b *MangaFox) Search(term string) ([]*Manga, error) {
 			Slug:   responseResults[i][2],
 			Genre:  responseResults[i][3],
 			Author: responseResults[i][4],
-			URL:    url}
+			URL:    u}
 		results = append(results, manga)
 	}
 
 	return results, nil
 }
 
+// URLPatterns implements Linker interface
+func (*MangaFox) URLPatterns() []*regexp.Regexp {
+	return []*regexp.Regexp{MangaFoxRegexpLink}
+}
+
+// Resolve implements Linker interface, manga name is read on manga page
+// and links are moved to MangaFoxBaseURL
+func (b *MangaFox) Resolve(u *url.URL) (*Link, error) {
+	parts, err := matchLink(b.URLPatterns(), u)
+	if err != nil {
+		return nil, err
+	}
+	err = parts.rebase(MangaFoxBaseURL)
+	if err != nil {
+		return nil, err
+	}
+
+	manga := linkManga(parts.manga)
+	doc, err := b.Client.For(client.RequestChapters).GetDocument(manga.URL, []int{200})
+	if err != nil {
+		return nil, err
+	}
+	if name, ok := doc.Find(MangaFoxHTMLSelectorMangaName).Attr("alt"); ok && len(name) > 0 {
+		manga.Name = name
+	}
+
+	return resolveLink(b, manga, parts)
+}
+
 // Chapters implements Backend interface
 func (b *MangaFox) Chapters(manga *Manga) ([]*Chapter, error) {
-	doc, err := b.Client.GetDocument(manga.URL, []int{200})
+	doc, err := b.Client.For(client.RequestChapters).GetDocument(manga.URL, []int{200})
 	if err != nil {
 		return nil, err
 	}
@@ -128,7 +167,7 @@ func (b *MangaFox) Chapters(manga *Manga) ([]*Chapter, error) {
 
 // Pages implements Backend interface
 func (b *MangaFox) Pages(chapter *Chapter) ([]*Page, error) {
-	doc, err := b.Client.GetDocument(chapter.URL, []int{200})
+	doc, err := b.Client.For(client.RequestPages).GetDocument(chapter.URL, []int{200})
 	if err != nil {
 		return nil, err
 	}
@@ -142,7 +181,7 @@ func (b *MangaFox) Pages(chapter *Chapter) ([]*Page, error) {
 		pageNumberString := htmlGetNodeAttribute(optionNode, "value")
 		pageNumber, err := strconv.Atoi(pageNumberString)
 		if err != nil {
-			return nil, err
+			return nil, &ParseError{Backend: b.Name(), URL: chapter.URL, Err: err}
 		}
 
 		if pageNumber <= 0 {
@@ -161,14 +200,14 @@ func (b *MangaFox) Pages(chapter *Chapter) ([]*Page, error) {
 
 // PageImageURL implements Backend interface
 func (b *MangaFox) PageImageURL(page *Page) (*url.URL, error) {
-	doc, err := b.Client.GetDocument(page.URL, []int{200})
+	doc, err := b.Client.For(client.RequestPage).GetDocument(page.URL, []int{200})
 	if err != nil {
 		return nil, err
 	}
 
 	imgNodes := doc.Find(MangaFoxHTMLSelectorPageImage).Nodes
 	if len(imgNodes) != 1 {
-		return nil, fmt.Errorf("html node '%s' (page image url) not found in '%s'", MangaFoxHTMLSelectorPageImage, page.URL)
+		return nil, &ParseError{Backend: b.Name(), Selector: MangaFoxHTMLSelectorPageImage, URL: page.URL}
 	}
 
 	imgNode := imgNodes[0]
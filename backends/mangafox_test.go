@@ -0,0 +1,63 @@
+package backends_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/toxinu/katago/backends"
+	"github.com/toxinu/katago/backends/backendtest"
+	"github.com/toxinu/katago/client"
+)
+
+func TestMangaFoxCassette(t *testing.T) {
+	server := replay(t, "testdata/mangafox.json")
+
+	walk, err := backendtest.Browse(&backends.MangaFox{Client: server.Client()}, "berserk")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectWalk(t, walk, 2, "Berserk 1", "http://mangafox.la/manga/berserk/v01/c001/1.html", 3,
+		"http://l.mfcdn.net/store/manga/1/01-001.0/compressed/berserk_001_001.jpg")
+	if len(walk.Chapters) != 3 || walk.Chapters[2].Name != "Berserk 3" {
+		t.Errorf("chapters are not read from both lists oldest first: %v", walk.Chapters)
+	}
+}
+
+func TestMangaFox(t *testing.T) {
+	server := replay(t, "testdata/mangafox.json")
+
+	suite := &backendtest.Suite{Backend: &backends.MangaFox{Client: server.Client()}, Term: "berserk", Client: server.Client()}
+	err := suite.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMangaFoxResolve(t *testing.T) {
+	server := replay(t, "testdata/mangafox.json")
+	b := &backends.MangaFox{Client: server.Client()}
+
+	tests := []struct {
+		link    string
+		chapter string
+		page    string
+	}{
+		{"http://mangafox.la/manga/berserk/", "", ""},
+		{"https://www.mangafox.la/manga/berserk/v01/c002/", "Berserk 2", ""},
+		{"https://mangafox.la/manga/berserk/v02/c003/4.html", "Berserk 3", "http://mangafox.la/manga/berserk/v02/c003/4.html"},
+	}
+	for _, test := range tests {
+		link, err := b.Resolve(mustParse(t, test.link))
+		if err != nil {
+			t.Errorf("%s: %s", test.link, err)
+			continue
+		}
+		expectLink(t, link, "Berserk", test.chapter, test.page)
+	}
+
+	_, err := b.Resolve(mustParse(t, "http://mangafox.la/manga/berserk/v01/c099/1.html"))
+	if !errors.Is(err, client.ErrNotFound) {
+		t.Errorf("got %v for a missing chapter, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,131 @@
+package backends
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	regexpChapterPrefixedNumber = regexp.MustCompile(`(?i)(?:^|[^a-z])ch(?:apter)?\.?\s*(\d+(?:\.\d+)?)`)
+	regexpChapterNumber         = regexp.MustCompile(`(?i)(?:^|/|\s|ch(?:apter)?\.?\s*|c)(\d+(?:\.\d+)?)/?(?:\d+\.html)?$`)
+	regexpChapterVolume         = regexp.MustCompile(`(?i)(?:^|[^a-z])v(?:ol(?:ume)?)?\.?\s*(\d+(?:\.\d+)?)(?:$|[^\d.])`)
+	regexpChapterURLVolume      = regexp.MustCompile(`(?i)/v(\d+(?:\.\d+)?)/`)
+)
+
+// ChapterNumber reads chapter number from chapter name, like "Vol. 2 Ch. 13
+// - Title" or "Naruto 13", or from chapter URL
+func ChapterNumber(chapter *Chapter) (float64, bool) {
+	name := strings.TrimSpace(chapter.Name)
+	candidates := []struct {
+		regexp *regexp.Regexp
+		text   string
+	}{
+		{regexpChapterPrefixedNumber, name},
+		{regexpChapterNumber, name},
+	}
+	if chapter.URL != nil {
+		candidates = append(candidates, struct {
+			regexp *regexp.Regexp
+			text   string
+		}{regexpChapterNumber, chapter.URL.Path})
+	}
+
+	for _, candidate := range candidates {
+		matches := candidate.regexp.FindStringSubmatch(candidate.text)
+		if matches == nil {
+			continue
+		}
+		number, err := strconv.ParseFloat(matches[1], 64)
+		if err == nil {
+			return number, true
+		}
+	}
+	return 0, false
+}
+
+// ChapterVolume reads chapter volume from chapter name, like "Vol. 2 Ch.
+// 13", or from chapter URL, like "/v02/c013/"
+func ChapterVolume(chapter *Chapter) (float64, bool) {
+	matches := regexpChapterVolume.FindStringSubmatch(chapter.Name)
+	if matches == nil && chapter.URL != nil {
+		matches = regexpChapterURLVolume.FindStringSubmatch(chapter.URL.Path)
+	}
+	if matches == nil {
+		return 0, false
+	}
+
+	volume, err := strconv.ParseFloat(matches[1], 64)
+	return volume, err == nil
+}
+
+// FindChapter returns the chapter being given one, found by number and by
+// volume when both chapters have one, nil when none is. A chapter whose
+// volume is known to match wins over ones without volume, and several
+// chapters matching alike are ambiguous so none is returned.
+func FindChapter(chapters []*Chapter, chapter *Chapter) *Chapter {
+	number, ok := ChapterNumber(chapter)
+	if !ok {
+		return nil
+	}
+	volume, hasVolume := ChapterVolume(chapter)
+
+	var same, loose []*Chapter
+	for _, candidate := range chapters {
+		if n, ok := ChapterNumber(candidate); !ok || n != number {
+			continue
+		}
+		v, ok := ChapterVolume(candidate)
+		switch {
+		case !ok || !hasVolume:
+			loose = append(loose, candidate)
+		case v == volume:
+			same = append(same, candidate)
+		}
+	}
+
+	switch {
+	case len(same) == 1:
+		return same[0]
+	case len(same) == 0 && len(loose) == 1:
+		return loose[0]
+	}
+	return nil
+}
+
+// SameSeries tells whether mangas found on different backends are the same
+// series, same normalised title and same author when both are known
+func SameSeries(a *Manga, b *Manga) bool {
+	if normaliseName(a.Name) != normaliseName(b.Name) {
+		return false
+	}
+
+	authorA, authorB := normaliseName(a.Author), normaliseName(b.Author)
+	return len(authorA) == 0 || len(authorB) == 0 || authorA == authorB
+}
+
+// FindSeries returns the result being the same series as given manga, nil
+// when none is. Without both authors known a title match is only trusted
+// when no other result has the same title.
+func FindSeries(manga *Manga, results []*Manga) *Manga {
+	var (
+		found   *Manga
+		matches int
+	)
+	for _, result := range results {
+		if !SameSeries(manga, result) {
+			continue
+		}
+		if len(normaliseName(manga.Author)) > 0 && len(normaliseName(result.Author)) > 0 {
+			return result
+		}
+		if found == nil {
+			found = result
+		}
+		matches++
+	}
+	if matches > 1 {
+		return nil
+	}
+	return found
+}
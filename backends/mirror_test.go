@@ -0,0 +1,101 @@
+package backends_test
+
+import (
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/toxinu/katago/backends"
+)
+
+func TestFindSeries(t *testing.T) {
+	berserk := &backends.Manga{Name: "Berserk", Author: "Miura Kentarou"}
+	unknown := &backends.Manga{Name: "Berserk"}
+	other := &backends.Manga{Name: "Berserk!", Author: "Someone Else"}
+	anonymous := &backends.Manga{Name: "berserk"}
+	tests := []struct {
+		name    string
+		manga   *backends.Manga
+		results []*backends.Manga
+		want    *backends.Manga
+	}{
+		{"same author", unknown, []*backends.Manga{berserk}, berserk},
+		{"known authors", &backends.Manga{Name: "BERSERK", Author: "miura kentarou"}, []*backends.Manga{other, berserk}, berserk},
+		{"other author", berserk, []*backends.Manga{other}, nil},
+		{"single title match", berserk, []*backends.Manga{anonymous, {Name: "Berserk Nights"}}, anonymous},
+		{"ambiguous title", unknown, []*backends.Manga{anonymous, other}, nil},
+		{"no match", berserk, []*backends.Manga{{Name: "Vagabond"}}, nil},
+	}
+
+	for _, test := range tests {
+		if got := backends.FindSeries(test.manga, test.results); got != test.want {
+			t.Errorf("%s: got %+v, want %+v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestFindChapter(t *testing.T) {
+	chapter := func(name string) *backends.Chapter {
+		return &backends.Chapter{Name: name, URL: &url.URL{Scheme: "https", Host: "katago.test", Path: "/" + name}}
+	}
+	v1c1, v1c2, v2c1 := chapter("Vol. 1 Ch. 1"), chapter("Vol. 1 Ch. 2"), chapter("Vol. 2 Ch. 1")
+	c1, c2 := chapter("Ch. 1"), chapter("Ch. 2")
+	tests := []struct {
+		name     string
+		chapter  *backends.Chapter
+		chapters []*backends.Chapter
+		want     *backends.Chapter
+	}{
+		{"number", chapter("Berserk 2"), []*backends.Chapter{c1, c2}, c2},
+		{"volume and number", chapter("Vol. 2 Ch. 1"), []*backends.Chapter{v1c1, v2c1}, v2c1},
+		{"other volume", chapter("Vol. 3 Ch. 1"), []*backends.Chapter{v1c1, v2c1}, nil},
+		{"candidate without volume", chapter("Vol. 2 Ch. 1"), []*backends.Chapter{c1, c2}, c1},
+		{"same volume first", chapter("Vol. 2 Ch. 1"), []*backends.Chapter{c1, v2c1}, v2c1},
+		{"chapter without volume", chapter("Ch. 2"), []*backends.Chapter{v1c1, v1c2}, v1c2},
+		{"ambiguous number", chapter("Ch. 1"), []*backends.Chapter{v1c1, v2c1}, nil},
+		{"duplicated chapter", chapter("Ch. 1"), []*backends.Chapter{c1, chapter("Chapter 1")}, nil},
+		{"unnumbered chapter", chapter("Extra"), []*backends.Chapter{c1, c2}, nil},
+	}
+
+	for _, test := range tests {
+		if got := backends.FindChapter(test.chapters, test.chapter); got != test.want {
+			t.Errorf("%s: got %+v, want %+v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestFollowUpdatesMatchesVolumes(t *testing.T) {
+	dir, err := ioutil.TempDir("", "katago-library-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, chapter := range []string{"Vol. 1 Ch. 1", "Vol. 1 Ch. 2", "Vol. 2 Ch. 2"} {
+		err = os.MkdirAll(filepath.Join(dir, "Berserk", chapter), 0755)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	manga := &backends.Manga{Name: "Berserk"}
+	var updates []*backends.Update
+	for _, name := range []string{"Vol. 2 Ch. 1", "Vol. 1 Ch. 1", "Ch. 1", "Ch. 2"} {
+		updates = append(updates, &backends.Update{Manga: manga, Chapter: &backends.Chapter{Name: name}})
+	}
+
+	followed, err := backends.FollowUpdates(&backends.Local{Dir: dir}, updates)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(followed) != len(updates) {
+		t.Fatalf("got %d followed updates, want %d", len(followed), len(updates))
+	}
+	for index, want := range []bool{false, true, true, false} {
+		if followed[index].Downloaded != want {
+			t.Errorf("%s: got downloaded %v, want %v", followed[index].Chapter.Name, followed[index].Downloaded, want)
+		}
+	}
+}
@@ -0,0 +1,467 @@
+package backends
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/toxinu/katago/client"
+)
+
+var (
+	// PluginTimeout is how long a plugin call is waited for by default
+	PluginTimeout = 2 * time.Minute
+	// PluginGracePeriod is how long a closed plugin is given to exit before
+	// being killed
+	PluginGracePeriod = 2 * time.Second
+)
+
+// Plugin is a backend implemented by an external executable speaking
+// JSON-RPC 2.0 over its stdin and stdout, one message per line
+type Plugin struct {
+	Slug string
+	Path string
+	// Timeout bounds every call, PluginTimeout when zero
+	Timeout time.Duration
+
+	mutex   sync.Mutex
+	name    string
+	filters *Filters
+	cmd     *exec.Cmd
+	stdin   io.WriteCloser
+	// done is closed once the plugin process exited
+	done    chan struct{}
+	lastID  int
+	pending map[int]chan *pluginResponse
+}
+
+// PluginError is an error returned by a plugin, codes 404 and 429 match
+// client.ErrNotFound and client.ErrRateLimited
+type PluginError struct {
+	Plugin  string
+	Code    int
+	Message string
+}
+
+func (e *PluginError) Error() string {
+	return fmt.Sprintf("plugin %s: %s (%d)", e.Plugin, e.Message, e.Code)
+}
+
+// Is implements errors.Is interface
+func (e *PluginError) Is(target error) bool {
+	switch target {
+	case client.ErrNotFound:
+		return e.Code == 404
+	case client.ErrRateLimited:
+		return e.Code == 429
+	default:
+		return false
+	}
+}
+
+type pluginRequest struct {
+	JSONRPC string      `json:"jsonrpc"`
+	ID      int         `json:"id"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params,omitempty"`
+}
+
+type pluginResponse struct {
+	ID     int             `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  *pluginRPCError `json:"error"`
+}
+
+type pluginRPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// pluginManga, pluginChapter and pluginPage are the wire representations
+// of Manga, Chapter and Page
+type pluginManga struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Slug   string `json:"slug"`
+	Author string `json:"author"`
+	Genre  string `json:"genre"`
+	URL    string `json:"url"`
+}
+
+type pluginQuery struct {
+	Term          string   `json:"term"`
+	Page          int      `json:"page"`
+	Sort          string   `json:"sort,omitempty"`
+	IncludeGenres []string `json:"include_genres,omitempty"`
+	ExcludeGenres []string `json:"exclude_genres,omitempty"`
+	Status        string   `json:"status,omitempty"`
+	Author        string   `json:"author,omitempty"`
+}
+
+type pluginFilters struct {
+	Paging        bool     `json:"paging"`
+	Sorts         []string `json:"sorts"`
+	IncludeGenres bool     `json:"include_genres"`
+	ExcludeGenres bool     `json:"exclude_genres"`
+	Statuses      []string `json:"statuses"`
+	Author        bool     `json:"author"`
+}
+
+type pluginChapter struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+type pluginPage struct {
+	URL string `json:"url"`
+}
+
+// LoadPlugins returns a Plugin for every executable of given directory,
+// named after the file without its extension
+func LoadPlugins(dir string) ([]*Plugin, error) {
+	files, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	plugins := make([]*Plugin, 0, len(files))
+	for _, file := range files {
+		if file.IsDir() || file.Mode()&0111 == 0 {
+			continue
+		}
+		plugins = append(plugins, &Plugin{
+			Slug: strings.TrimSuffix(file.Name(), filepath.Ext(file.Name())),
+			Path: filepath.Join(dir, file.Name()),
+		})
+	}
+	return plugins, nil
+}
+
+// start runs the plugin process, mutex must be held
+func (p *Plugin) start() error {
+	if p.cmd != nil {
+		return nil
+	}
+
+	cmd := exec.Command(p.Path)
+	cmd.Stderr = os.Stderr
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+
+	err = cmd.Start()
+	if err != nil {
+		return fmt.Errorf("plugin %s: %s", p.Slug, err)
+	}
+
+	p.cmd = cmd
+	p.stdin = stdin
+	p.done = make(chan struct{})
+	p.pending = map[int]chan *pluginResponse{}
+
+	go p.read(cmd, stdout, p.done)
+	return nil
+}
+
+// read dispatches responses until the plugin stdout is closed or a line is
+// not a response, the plugin is then stopped and its pending calls fail
+func (p *Plugin) read(cmd *exec.Cmd, stdout io.Reader, done chan struct{}) {
+	defer close(done)
+
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	message := "plugin exited"
+	for scanner.Scan() {
+		response := &pluginResponse{}
+		err := json.Unmarshal(scanner.Bytes(), response)
+		if err != nil {
+			message = fmt.Sprintf("invalid response: %s", err)
+			break
+		}
+
+		p.mutex.Lock()
+		pending, ok := p.pending[response.ID]
+		delete(p.pending, response.ID)
+		p.mutex.Unlock()
+
+		if ok {
+			pending <- response
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		message = fmt.Sprintf("invalid response: %s", err)
+	}
+	// The plugin cannot answer anymore, it is killed in case it still runs
+	cmd.Process.Kill()
+
+	p.mutex.Lock()
+	if p.cmd == cmd {
+		for id, pending := range p.pending {
+			pending <- &pluginResponse{ID: id, Error: &pluginRPCError{Code: -32000, Message: message}}
+		}
+		p.cmd = nil
+		p.pending = nil
+	}
+	p.mutex.Unlock()
+
+	cmd.Wait()
+}
+
+// call sends a request and decodes its result, it stops waiting for it
+// once given context is done
+func (p *Plugin) call(ctx context.Context, method string, params interface{}, result interface{}) error {
+	p.mutex.Lock()
+	err := p.start()
+	if err != nil {
+		p.mutex.Unlock()
+		return err
+	}
+
+	p.lastID++
+	request := &pluginRequest{JSONRPC: "2.0", ID: p.lastID, Method: method, Params: params}
+	pending := make(chan *pluginResponse, 1)
+	p.pending[request.ID] = pending
+
+	data, err := json.Marshal(request)
+	if err == nil {
+		_, err = p.stdin.Write(append(data, '\n'))
+	}
+	if err != nil {
+		delete(p.pending, request.ID)
+		p.mutex.Unlock()
+		return fmt.Errorf("plugin %s: %s", p.Slug, err)
+	}
+	p.mutex.Unlock()
+
+	timeout := p.Timeout
+	if timeout <= 0 {
+		timeout = PluginTimeout
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	var response *pluginResponse
+	select {
+	case response = <-pending:
+	case <-timer.C:
+		p.mutex.Lock()
+		delete(p.pending, request.ID)
+		p.mutex.Unlock()
+		return fmt.Errorf("plugin %s: %s timed out after %s", p.Slug, method, timeout)
+	case <-ctx.Done():
+		p.mutex.Lock()
+		delete(p.pending, request.ID)
+		p.mutex.Unlock()
+		return fmt.Errorf("plugin %s: %s %w: %s", p.Slug, method, client.ErrCancelled, ctx.Err())
+	}
+	if response.Error != nil {
+		return &PluginError{Plugin: p.Slug, Code: response.Error.Code, Message: response.Error.Message}
+	}
+
+	err = json.Unmarshal(response.Result, result)
+	if err != nil {
+		return fmt.Errorf("plugin %s: invalid %s result: %s", p.Slug, method, err)
+	}
+	return nil
+}
+
+// Close stops the plugin process, closing its stdin and killing it when
+// it is still running after PluginGracePeriod
+func (p *Plugin) Close() error {
+	p.mutex.Lock()
+	cmd, stdin, done := p.cmd, p.stdin, p.done
+	p.mutex.Unlock()
+
+	if cmd == nil {
+		return nil
+	}
+
+	err := stdin.Close()
+	select {
+	case <-done:
+	case <-time.After(PluginGracePeriod):
+		cmd.Process.Kill()
+		<-done
+	}
+	return err
+}
+
+// Name implements Backend interface
+func (p *Plugin) Name() string {
+	p.mutex.Lock()
+	name := p.name
+	p.mutex.Unlock()
+	if len(name) > 0 {
+		return name
+	}
+
+	err := p.call(context.Background(), "name", nil, &name)
+	if err != nil || len(name) == 0 {
+		return p.Slug
+	}
+
+	p.mutex.Lock()
+	p.name = name
+	p.mutex.Unlock()
+	return name
+}
+
+// Filters implements Filterer interface, plugins not implementing the
+// filters method only support a search term
+func (p *Plugin) Filters() Filters {
+	p.mutex.Lock()
+	filters := p.filters
+	p.mutex.Unlock()
+	if filters != nil {
+		return *filters
+	}
+
+	result := &pluginFilters{}
+	err := p.call(context.Background(), "filters", nil, result)
+	if err != nil {
+		result = &pluginFilters{}
+	}
+
+	filters = &Filters{
+		Paging:        result.Paging,
+		Sorts:         result.Sorts,
+		IncludeGenres: result.IncludeGenres,
+		ExcludeGenres: result.ExcludeGenres,
+		Statuses:      result.Statuses,
+		Author:        result.Author,
+	}
+
+	p.mutex.Lock()
+	p.filters = filters
+	p.mutex.Unlock()
+	return *filters
+}
+
+// Search implements Backend interface
+func (p *Plugin) Search(query *Query) ([]*Manga, error) {
+	params := map[string]*pluginQuery{"query": {
+		Term:          query.Term,
+		Page:          query.Page,
+		Sort:          query.Sort,
+		IncludeGenres: query.IncludeGenres,
+		ExcludeGenres: query.ExcludeGenres,
+		Status:        query.Status,
+		Author:        query.Author,
+	}}
+
+	var results []*pluginManga
+	err := p.call(query.Context(), "search", params, &results)
+	if err != nil {
+		return nil, err
+	}
+
+	mangas := make([]*Manga, 0, len(results))
+	for _, result := range results {
+		mangaURL, err := p.parseURL(result.URL)
+		if err != nil {
+			return nil, err
+		}
+		mangas = append(mangas, &Manga{
+			ID:     result.ID,
+			Name:   result.Name,
+			Slug:   result.Slug,
+			Author: result.Author,
+			Genre:  result.Genre,
+			URL:    mangaURL,
+		})
+	}
+	return mangas, nil
+}
+
+// Chapters implements Backend interface
+func (p *Plugin) Chapters(manga *Manga) ([]*Chapter, error) {
+	params := map[string]*pluginManga{"manga": {
+		ID:     manga.ID,
+		Name:   manga.Name,
+		Slug:   manga.Slug,
+		Author: manga.Author,
+		Genre:  manga.Genre,
+		URL:    manga.URL.String(),
+	}}
+
+	var results []*pluginChapter
+	err := p.call(context.Background(), "chapters", params, &results)
+	if err != nil {
+		return nil, err
+	}
+
+	chapters := make([]*Chapter, 0, len(results))
+	for _, result := range results {
+		chapterURL, err := p.parseURL(result.URL)
+		if err != nil {
+			return nil, err
+		}
+		chapters = append(chapters, &Chapter{Name: result.Name, URL: chapterURL})
+	}
+	return chapters, nil
+}
+
+// Pages implements Backend interface
+func (p *Plugin) Pages(chapter *Chapter) ([]*Page, error) {
+	params := map[string]*pluginChapter{"chapter": {Name: chapter.Name, URL: chapter.URL.String()}}
+
+	var results []*pluginPage
+	err := p.call(context.Background(), "pages", params, &results)
+	if err != nil {
+		return nil, err
+	}
+
+	pages := make([]*Page, 0, len(results))
+	for _, result := range results {
+		pageURL, err := p.parseURL(result.URL)
+		if err != nil {
+			return nil, err
+		}
+		pages = append(pages, &Page{URL: pageURL})
+	}
+	return pages, nil
+}
+
+// PageImageURL implements Backend interface
+func (p *Plugin) PageImageURL(page *Page) (*url.URL, error) {
+	params := map[string]*pluginPage{"page": {URL: page.URL.String()}}
+
+	var result string
+	err := p.call(context.Background(), "page_image_url", params, &result)
+	if err != nil {
+		return nil, err
+	}
+	return p.parseURL(result)
+}
+
+func (p *Plugin) parseURL(raw string) (*url.URL, error) {
+	u, err := url.Parse(raw)
+	if err != nil || !u.IsAbs() {
+		if err == nil {
+			err = errors.New("URL is not absolute")
+		}
+		return nil, fmt.Errorf("plugin %s: invalid URL '%s': %s", p.Slug, raw, err)
+	}
+	return u, nil
+}
@@ -0,0 +1,228 @@
+package backends_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/toxinu/katago/backends"
+	"github.com/toxinu/katago/backends/backendtest"
+	"github.com/toxinu/katago/client"
+)
+
+// testPluginEnv makes the test binary run as a plugin behaving as the
+// variable value tells
+const testPluginEnv = "KATAGO_TEST_PLUGIN"
+
+func TestMain(m *testing.M) {
+	if behavior := os.Getenv(testPluginEnv); len(behavior) > 0 {
+		runTestPlugin(behavior)
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// testPluginImagesEnv is the URL the "serve" test plugin images are served
+// from
+const testPluginImagesEnv = "KATAGO_TEST_PLUGIN_IMAGES"
+
+// runTestPlugin answers "name" calls, and other calls as behavior tells:
+// "serve" answers them from a library of one manga, "hang" never answers
+// them, "garbage" answers a line which is not JSON and "stubborn" ignores
+// its stdin being closed
+func runTestPlugin(behavior string) {
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		request := struct {
+			ID     int             `json:"id"`
+			Method string          `json:"method"`
+			Params json.RawMessage `json:"params"`
+		}{}
+		json.Unmarshal(scanner.Bytes(), &request)
+
+		switch {
+		case request.Method == "name":
+			fmt.Printf(`{"jsonrpc":"2.0","id":%d,"result":"Test"}`+"\n", request.ID)
+		case behavior == "serve":
+			response := map[string]interface{}{"jsonrpc": "2.0", "id": request.ID}
+			result, ok := serveTestPlugin(request.Method, request.Params)
+			if ok {
+				response["result"] = result
+			} else {
+				response["error"] = map[string]interface{}{"code": 404, "message": "not found"}
+			}
+			data, _ := json.Marshal(response)
+			fmt.Println(string(data))
+		case behavior == "garbage":
+			fmt.Println("Traceback (most recent call last):")
+		}
+	}
+
+	if behavior == "stubborn" {
+		time.Sleep(time.Hour)
+	}
+}
+
+// serveTestPlugin answers a call of the "serve" test plugin, its library
+// is Berserk, having 3 chapters of 2 pages
+func serveTestPlugin(method string, params json.RawMessage) (interface{}, bool) {
+	const base = "https://plugin.test/berserk"
+	values := struct {
+		Query   struct{ Term string }
+		Manga   struct{ URL string }
+		Chapter struct{ URL string }
+		Page    struct{ URL string }
+	}{}
+	json.Unmarshal(params, &values)
+
+	var chapter, page int
+	switch method {
+	case "search":
+		if !strings.Contains("berserk", strings.ToLower(values.Query.Term)) {
+			return []interface{}{}, true
+		}
+		return []map[string]string{{"id": "berserk", "name": "Berserk", "slug": "berserk", "author": "Miura Kentarou", "url": base}}, true
+	case "chapters":
+		if values.Manga.URL != base {
+			return nil, false
+		}
+		var chapters []map[string]string
+		for c := 1; c <= 3; c++ {
+			chapters = append(chapters, map[string]string{"name": fmt.Sprintf("Berserk %d", c), "url": fmt.Sprintf("%s/c%03d", base, c)})
+		}
+		return chapters, true
+	case "pages":
+		_, err := fmt.Sscanf(values.Chapter.URL, base+"/c%03d", &chapter)
+		if err != nil || chapter < 1 || chapter > 3 {
+			return nil, false
+		}
+		var pages []map[string]string
+		for p := 1; p <= 2; p++ {
+			pages = append(pages, map[string]string{"url": fmt.Sprintf("%s/%d", values.Chapter.URL, p)})
+		}
+		return pages, true
+	case "page_image_url":
+		_, err := fmt.Sscanf(values.Page.URL, base+"/c%03d/%d", &chapter, &page)
+		if err != nil {
+			return nil, false
+		}
+		return fmt.Sprintf("%s/c%03d/%d.png", os.Getenv(testPluginImagesEnv), chapter, page), true
+	}
+	return nil, false
+}
+
+// startTestPlugin returns a Plugin run by the test binary with given
+// behavior, started by a name call
+func startTestPlugin(t *testing.T, behavior string) *backends.Plugin {
+	os.Setenv(testPluginEnv, behavior)
+	defer os.Unsetenv(testPluginEnv)
+
+	p := &backends.Plugin{Slug: "test", Path: os.Args[0]}
+	if name := p.Name(); name != "Test" {
+		t.Fatalf("got name %q, plugin did not start", name)
+	}
+	return p
+}
+
+// expectWithin fails when f takes longer than given duration
+func expectWithin(t *testing.T, duration time.Duration, f func()) {
+	t.Helper()
+
+	done := make(chan struct{})
+	go func() {
+		f()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(duration):
+		t.Fatalf("still waiting after %s", duration)
+	}
+}
+
+func TestPluginCallTimeout(t *testing.T) {
+	p := startTestPlugin(t, "hang")
+	defer p.Close()
+	p.Timeout = 100 * time.Millisecond
+
+	expectWithin(t, 5*time.Second, func() {
+		_, err := p.Search(backends.NewQuery("berserk"))
+		if err == nil || !strings.Contains(err.Error(), "timed out") {
+			t.Errorf("got %v, want a timeout", err)
+		}
+	})
+}
+
+func TestPluginMalformedResponse(t *testing.T) {
+	p := startTestPlugin(t, "garbage")
+	defer p.Close()
+
+	expectWithin(t, 5*time.Second, func() {
+		_, err := p.Search(backends.NewQuery("berserk"))
+		if err == nil || !strings.Contains(err.Error(), "invalid response") {
+			t.Errorf("got %v, want an invalid response error", err)
+		}
+	})
+}
+
+func TestPluginCloseKillsStubbornProcess(t *testing.T) {
+	gracePeriod := backends.PluginGracePeriod
+	backends.PluginGracePeriod = 100 * time.Millisecond
+	defer func() { backends.PluginGracePeriod = gracePeriod }()
+
+	p := startTestPlugin(t, "stubborn")
+
+	expectWithin(t, 5*time.Second, func() { p.Close() })
+}
+
+func TestPlugin(t *testing.T) {
+	images := backendtest.NewImageServer()
+	defer images.Close()
+	os.Setenv(testPluginImagesEnv, images.URL)
+	defer os.Unsetenv(testPluginImagesEnv)
+
+	p := startTestPlugin(t, "serve")
+	defer p.Close()
+
+	results, err := p.Search(backends.NewQuery("berserk"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Name != "Berserk" || results[0].Author != "Miura Kentarou" {
+		t.Fatalf("got results %v, want Berserk", results)
+	}
+	chapters, err := p.Chapters(results[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chapters) != 3 || chapters[2].Name != "Berserk 3" {
+		t.Fatalf("got chapters %v, want Berserk 1 to 3", chapters)
+	}
+	pages, err := p.Pages(chapters[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pages) != 2 || pages[1].URL.String() != "https://plugin.test/berserk/c003/2" {
+		t.Fatalf("got pages %v, want 2 pages of Berserk 3", pages)
+	}
+	imageURL, err := p.PageImageURL(pages[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if imageURL.String() != images.URL+"/c003/2.png" {
+		t.Errorf("got image URL %s, want %s", imageURL, images.URL+"/c003/2.png")
+	}
+
+	c := client.NewClient()
+	c.Limiter = nil
+	suite := &backendtest.Suite{Backend: p, Term: "berserk", Client: c}
+	err = suite.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,169 @@
+package backends
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Search orders
+const (
+	SortRelevance  = "relevance"
+	SortPopularity = "popularity"
+	SortLatest     = "latest"
+	SortTitle      = "title"
+)
+
+// Publication statuses
+const (
+	StatusOngoing   = "ongoing"
+	StatusCompleted = "completed"
+)
+
+// Query represents a search, zero values are not filtered on
+type Query struct {
+	Term string
+	// Page is the results page, starting at 1
+	Page int
+	// Sort is one of the Sort constants
+	Sort          string
+	IncludeGenres []string
+	ExcludeGenres []string
+	// Status is one of the Status constants
+	Status string
+	Author string
+
+	ctx context.Context
+}
+
+// NewQuery returns a Query searching given term
+func NewQuery(term string) *Query {
+	return &Query{Term: term, Page: 1}
+}
+
+// WithContext returns a copy of Query bound to given Context, backends stop
+// searching once it is done
+func (q *Query) WithContext(ctx context.Context) *Query {
+	queryCopy := *q
+	queryCopy.ctx = ctx
+	return &queryCopy
+}
+
+// Context returns the Context the Query is bound to, context.Background()
+// when unset
+func (q *Query) Context() context.Context {
+	if q.ctx == nil {
+		return context.Background()
+	}
+	return q.ctx
+}
+
+// Filters tells which Query fields a backend supports, Term always is
+type Filters struct {
+	Paging bool
+	// Sorts are supported Sort constants
+	Sorts         []string
+	IncludeGenres bool
+	ExcludeGenres bool
+	// Statuses are supported Status constants
+	Statuses []string
+	Author   bool
+}
+
+// Filterer is implemented by backends supporting more than a search term
+type Filterer interface {
+	Filters() Filters
+}
+
+// SupportedFilters returns the filters given backend supports
+func SupportedFilters(b Backend) Filters {
+	if filterer, ok := b.(Filterer); ok {
+		return filterer.Filters()
+	}
+	return Filters{}
+}
+
+// ErrUnsupportedFilter is matched by UnsupportedFilterError
+var ErrUnsupportedFilter = errors.New("unsupported filter")
+
+// UnsupportedFilterError is returned when a Query uses a filter its backend
+// does not support
+type UnsupportedFilterError struct {
+	Backend string
+	Filter  string
+}
+
+func (e *UnsupportedFilterError) Error() string {
+	return fmt.Sprintf("%s does not support %s", e.Backend, e.Filter)
+}
+
+// Is implements errors.Is interface
+func (e *UnsupportedFilterError) Is(target error) bool {
+	return target == ErrUnsupportedFilter
+}
+
+// Check returns an UnsupportedFilterError if the Query uses a filter not in
+// given filters
+func (q *Query) Check(backend string, f Filters) error {
+	unsupported := func(filter string) error {
+		return &UnsupportedFilterError{Backend: backend, Filter: filter}
+	}
+
+	switch {
+	case q.Page > 1 && !f.Paging:
+		return unsupported("paging")
+	case len(q.Sort) > 0 && q.Sort != SortRelevance && !stringSliceContains(f.Sorts, q.Sort):
+		return unsupported(fmt.Sprintf("sorting by %s", q.Sort))
+	case len(q.IncludeGenres) > 0 && !f.IncludeGenres:
+		return unsupported("genre filters")
+	case len(q.ExcludeGenres) > 0 && !f.ExcludeGenres:
+		return unsupported("genre exclusions")
+	case len(q.Status) > 0 && !stringSliceContains(f.Statuses, q.Status):
+		return unsupported(fmt.Sprintf("%s status filter", q.Status))
+	case len(q.Author) > 0 && !f.Author:
+		return unsupported("author filter")
+	}
+	return nil
+}
+
+// Search checks given backend supports the Query before running it
+func Search(b Backend, q *Query) ([]*Manga, error) {
+	err := q.Check(b.Name(), SupportedFilters(b))
+	if err != nil {
+		return nil, err
+	}
+	return b.Search(q)
+}
+
+// Empty tells no filter is supported
+func (f Filters) Empty() bool {
+	return !f.Paging && len(f.Sorts) == 0 && !f.IncludeGenres && !f.ExcludeGenres && len(f.Statuses) == 0 && !f.Author
+}
+
+// String describes supported filters, like "paging, sort (latest, title)"
+func (f Filters) String() string {
+	names := make([]string, 0, 6)
+	if f.Paging {
+		names = append(names, "paging")
+	}
+	if len(f.Sorts) > 0 {
+		names = append(names, fmt.Sprintf("sort (%s)", strings.Join(f.Sorts, ", ")))
+	}
+	if f.IncludeGenres {
+		names = append(names, "genre")
+	}
+	if f.ExcludeGenres {
+		names = append(names, "genre exclusion")
+	}
+	if len(f.Statuses) > 0 {
+		names = append(names, fmt.Sprintf("status (%s)", strings.Join(f.Statuses, ", ")))
+	}
+	if f.Author {
+		names = append(names, "author")
+	}
+	if len(names) == 0 {
+		return "none"
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,34 @@
+package backends_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/toxinu/katago/backends"
+	"github.com/toxinu/katago/backends/backendtest"
+	"github.com/toxinu/katago/client"
+)
+
+func TestSearchUnsupportedFilter(t *testing.T) {
+	memory := backendtest.NewMemory(nil)
+	memory.AddManga("Berserk", 1, 1)
+
+	query := backends.NewQuery("berserk")
+	query.Author = "Miura"
+	_, err := backends.Search(memory, query)
+	var filterError *backends.UnsupportedFilterError
+	if !errors.Is(err, backends.ErrUnsupportedFilter) || !errors.As(err, &filterError) {
+		t.Fatalf("got %v, want an UnsupportedFilterError", err)
+	}
+	if filterError.Filter != "author filter" {
+		t.Errorf("got filter %q, want %q", filterError.Filter, "author filter")
+	}
+
+	if errors.Is(client.ErrNotFound, backends.ErrUnsupportedFilter) {
+		t.Error("other errors match ErrUnsupportedFilter")
+	}
+	results, err := backends.Search(memory, backends.NewQuery("berserk"))
+	if err != nil || len(results) != 1 {
+		t.Errorf("got %d results and %v, want 1 result", len(results), err)
+	}
+}
@@ -0,0 +1,427 @@
+package backends
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/toxinu/katago/client"
+)
+
+// Scraper is a backend driven by a Definition
+type Scraper struct {
+	Definition *Definition
+	Client     *client.Client
+}
+
+// Name implements Backend interface
+func (b *Scraper) Name() string {
+	return b.Definition.Name
+}
+
+// Filters implements Filterer interface, definitions whose search URL has
+// a {page} placeholder support paging
+func (b *Scraper) Filters() Filters {
+	return Filters{Paging: strings.Contains(b.Definition.Search.URL, "{page}")}
+}
+
+// Search implements Backend interface
+func (b *Scraper) Search(query *Query) ([]*Manga, error) {
+	d := b.Definition
+
+	page := query.Page
+	if page < 1 {
+		page = 1
+	}
+
+	searchURL, err := url.Parse(strings.NewReplacer(
+		"{base}", strings.TrimSuffix(d.BaseURL, "/"),
+		"{term}", url.QueryEscape(query.Term),
+		"{page}", strconv.Itoa(page),
+	).Replace(d.Search.URL))
+	if err != nil {
+		return nil, err
+	}
+
+	c := b.Client.For(client.RequestSearch).WithContext(query.Context())
+	if d.Search.Format == "json" {
+		return b.searchJSON(c, searchURL)
+	}
+
+	doc, err := c.GetDocument(searchURL, []int{200})
+	if err != nil {
+		return nil, err
+	}
+
+	items := doc.Find(d.Search.Results)
+	results := make([]*Manga, 0, items.Length())
+	for i := range items.Nodes {
+		item := items.Eq(i)
+
+		manga, err := b.manga(searchURL,
+			d.Search.Name.html(item),
+			d.Search.Link.html(item),
+			d.Search.Author.html(item),
+			d.Search.Genre.html(item))
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, manga)
+	}
+
+	return results, nil
+}
+
+func (b *Scraper) searchJSON(c *client.Client, searchURL *url.URL) ([]*Manga, error) {
+	d := b.Definition
+
+	resp, err := c.Get(searchURL, []int{200})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var root interface{}
+	err = json.NewDecoder(resp.Body).Decode(&root)
+	if err != nil {
+		return nil, &ParseError{Backend: b.Name(), URL: searchURL, Err: err}
+	}
+
+	items, ok := jsonPath(root, d.Search.Results).([]interface{})
+	if !ok {
+		return nil, &ParseError{Backend: b.Name(), URL: searchURL, Err: fmt.Errorf("'%s' is not a list", d.Search.Results)}
+	}
+
+	results := make([]*Manga, 0, len(items))
+	for _, item := range items {
+		manga, err := b.manga(searchURL,
+			d.Search.Name.json(item),
+			d.Search.Link.json(item),
+			d.Search.Author.json(item),
+			d.Search.Genre.json(item))
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, manga)
+	}
+
+	return results, nil
+}
+
+func (b *Scraper) manga(searchURL *url.URL, name string, link string, author string, genre string) (*Manga, error) {
+	if len(link) == 0 {
+		return nil, &ParseError{Backend: b.Name(), Selector: b.Definition.Search.Link.Selector, URL: searchURL}
+	}
+
+	mangaURL, err := b.resolve(searchURL, link)
+	if err != nil {
+		return nil, err
+	}
+
+	slug := path.Base(strings.TrimSuffix(mangaURL.Path, "/"))
+	return &Manga{
+		ID:     slug,
+		Name:   name,
+		Slug:   slug,
+		Author: author,
+		Genre:  genre,
+		URL:    mangaURL,
+	}, nil
+}
+
+// Supports implements Supporter interface, definitions without latest URL
+// do not list latest updates
+func (b *Scraper) Supports(capability Capability) bool {
+	return capability != CapabilityLatest || len(b.Definition.Latest.URL) > 0
+}
+
+// Latest implements Updater interface
+func (b *Scraper) Latest(page int) ([]*Update, error) {
+	d := b.Definition
+
+	if page < 1 {
+		page = 1
+	}
+
+	latestURL, err := url.Parse(strings.NewReplacer(
+		"{base}", strings.TrimSuffix(d.BaseURL, "/"),
+		"{page}", strconv.Itoa(page),
+	).Replace(d.Latest.URL))
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := b.Client.For(client.RequestLatest).GetDocument(latestURL, []int{200})
+	if err != nil {
+		return nil, err
+	}
+
+	items := doc.Find(d.Latest.Items)
+	updates := make([]*Update, 0, items.Length())
+	for i := range items.Nodes {
+		item := items.Eq(i)
+
+		mangaLink := d.Latest.MangaLink.html(item)
+		if len(mangaLink) == 0 {
+			return nil, &ParseError{Backend: b.Name(), Selector: d.Latest.MangaLink.Selector, URL: latestURL}
+		}
+		manga, err := b.manga(latestURL, d.Latest.MangaName.html(item), mangaLink, "", "")
+		if err != nil {
+			return nil, err
+		}
+
+		chapterLink := d.Latest.ChapterLink.html(item)
+		if len(chapterLink) == 0 {
+			return nil, &ParseError{Backend: b.Name(), Selector: d.Latest.ChapterLink.Selector, URL: latestURL}
+		}
+		chapterURL, err := b.resolve(latestURL, chapterLink)
+		if err != nil {
+			return nil, err
+		}
+
+		update := &Update{Manga: manga, Chapter: &Chapter{Name: d.Latest.ChapterName.html(item), URL: chapterURL}}
+		if len(d.Latest.DateLayout) > 0 {
+			if date, err := time.Parse(d.Latest.DateLayout, d.Latest.Date.html(item)); err == nil {
+				update.Date = date
+			}
+		}
+		updates = append(updates, update)
+	}
+
+	return updates, nil
+}
+
+// URLPatterns implements Linker interface
+func (b *Scraper) URLPatterns() []*regexp.Regexp {
+	return b.Definition.Links.patterns
+}
+
+// Resolve implements Linker interface, links are moved to the definition
+// base URL
+func (b *Scraper) Resolve(u *url.URL) (*Link, error) {
+	d := b.Definition
+
+	parts, err := matchLink(b.URLPatterns(), u)
+	if err != nil {
+		return nil, err
+	}
+	err = parts.rebase(d.BaseURL)
+	if err != nil {
+		return nil, err
+	}
+
+	manga := linkManga(parts.manga)
+	if len(d.Links.Title.Selector) > 0 {
+		doc, err := b.Client.For(client.RequestChapters).GetDocument(manga.URL, []int{200})
+		if err != nil {
+			return nil, err
+		}
+		if name := d.Links.Title.html(doc.Selection); len(name) > 0 {
+			manga.Name = name
+		}
+	}
+
+	return resolveLink(b, manga, parts)
+}
+
+// Chapters implements Backend interface
+func (b *Scraper) Chapters(manga *Manga) ([]*Chapter, error) {
+	d := b.Definition
+
+	doc, err := b.Client.For(client.RequestChapters).GetDocument(manga.URL, []int{200})
+	if err != nil {
+		return nil, err
+	}
+
+	items := doc.Find(d.Chapters.Items)
+	chapters := make([]*Chapter, 0, items.Length())
+	for i := range items.Nodes {
+		item := items.Eq(i)
+
+		link := d.Chapters.Link.html(item)
+		if len(link) == 0 {
+			return nil, &ParseError{Backend: b.Name(), Selector: d.Chapters.Link.Selector, URL: manga.URL}
+		}
+
+		chapterURL, err := b.resolve(manga.URL, link)
+		if err != nil {
+			return nil, err
+		}
+
+		chapters = append(chapters, &Chapter{Name: d.Chapters.Name.html(item), URL: chapterURL})
+	}
+
+	if d.Chapters.Order == NewestFirst {
+		chapters = chapterSliceReverse(chapters)
+	}
+
+	return chapters, nil
+}
+
+// Pages implements Backend interface
+func (b *Scraper) Pages(chapter *Chapter) ([]*Page, error) {
+	d := b.Definition
+
+	doc, err := b.Client.For(client.RequestPages).GetDocument(chapter.URL, []int{200})
+	if err != nil {
+		return nil, err
+	}
+
+	chapterDir := chapter.URL.ResolveReference(&url.URL{Path: "."})
+	replacer := func(value string) string {
+		return strings.NewReplacer(
+			"{chapter}", chapter.URL.String(),
+			"{chapter_dir}", chapterDir.String(),
+			"{value}", value,
+		).Replace(d.Pages.URL)
+	}
+
+	items := doc.Find(d.Pages.Items)
+	pages := make([]*Page, 0, items.Length())
+	seen := map[string]bool{}
+	for i := range items.Nodes {
+		value := d.Pages.Value.html(items.Eq(i))
+		if len(value) == 0 || (d.Pages.skip != nil && d.Pages.skip.MatchString(value)) {
+			continue
+		}
+
+		if d.Pages.Strategy == PagesTemplate {
+			value = replacer(value)
+		}
+
+		pageURL, err := b.resolve(chapter.URL, value)
+		if err != nil {
+			return nil, err
+		}
+
+		if seen[pageURL.String()] {
+			continue
+		}
+		seen[pageURL.String()] = true
+
+		pages = append(pages, &Page{URL: pageURL})
+	}
+
+	return pages, nil
+}
+
+// PageImageURL implements Backend interface
+func (b *Scraper) PageImageURL(page *Page) (*url.URL, error) {
+	d := b.Definition
+
+	if d.Pages.Strategy == PagesImages {
+		return page.URL, nil
+	}
+
+	doc, err := b.Client.For(client.RequestPage).GetDocument(page.URL, []int{200})
+	if err != nil {
+		return nil, err
+	}
+
+	value := d.Image.html(doc.Selection)
+	if len(value) == 0 {
+		return nil, &ParseError{Backend: b.Name(), Selector: d.Image.Selector, URL: page.URL}
+	}
+
+	return b.resolve(page.URL, value)
+}
+
+// resolve normalises an URL read from given page
+func (b *Scraper) resolve(base *url.URL, raw string) (*url.URL, error) {
+	rules := b.Definition.URLRules
+
+	for _, replace := range rules.Replace {
+		raw = replace.pattern.ReplaceAllString(raw, replace.With)
+	}
+
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return nil, &ParseError{Backend: b.Name(), URL: base, Err: err}
+	}
+
+	u = base.ResolveReference(u)
+	if rules.StripQuery {
+		u.RawQuery = ""
+	}
+	return u, nil
+}
+
+func (f *Field) html(item *goquery.Selection) string {
+	selection := item
+	if len(f.Selector) > 0 {
+		selection = item.Find(f.Selector).First()
+	}
+
+	var value string
+	if len(f.Attribute) > 0 {
+		value, _ = selection.Attr(f.Attribute)
+	} else {
+		value = selection.Text()
+	}
+	return f.match(strings.TrimSpace(value))
+}
+
+func (f *Field) json(item interface{}) string {
+	if len(f.Key) == 0 {
+		return ""
+	}
+
+	switch value := jsonPath(item, f.Key).(type) {
+	case nil:
+		return ""
+	case string:
+		return f.match(strings.TrimSpace(value))
+	case float64:
+		return f.match(strconv.FormatFloat(value, 'f', -1, 64))
+	default:
+		return f.match(fmt.Sprint(value))
+	}
+}
+
+func (f *Field) match(value string) string {
+	if f.regexp != nil {
+		matches := f.regexp.FindStringSubmatch(value)
+		switch len(matches) {
+		case 0:
+			value = ""
+		case 1:
+			value = matches[0]
+		default:
+			value = matches[1]
+		}
+	}
+
+	if len(f.Template) > 0 && len(value) > 0 {
+		value = strings.Replace(f.Template, "{value}", value, -1)
+	}
+	return value
+}
+
+// jsonPath reads a dotted path in decoded JSON, list indexes are numbers
+func jsonPath(value interface{}, path string) interface{} {
+	if len(path) == 0 {
+		return value
+	}
+
+	for _, key := range strings.Split(path, ".") {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			value = v[key]
+		case []interface{}:
+			index, err := strconv.Atoi(key)
+			if err != nil || index < 0 || index >= len(v) {
+				return nil
+			}
+			value = v[index]
+		default:
+			return nil
+		}
+	}
+	return value
+}
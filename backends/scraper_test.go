@@ -0,0 +1,63 @@
+package backends_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/toxinu/katago/backends"
+	"github.com/toxinu/katago/backends/backendtest"
+)
+
+// mangaFoxDefinition loads the MangaFox definition shipped with katago
+func mangaFoxDefinition(t *testing.T) *backends.Definition {
+	definition, err := backends.LoadDefinition("../definitions/mangafox.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return definition
+}
+
+func TestScraperCassette(t *testing.T) {
+	server := replay(t, "testdata/mangafox-definition.json")
+
+	walk, err := backendtest.Browse(&backends.Scraper{Definition: mangaFoxDefinition(t), Client: server.Client()}, "berserk")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectWalk(t, walk, 2, "Berserk 1", "http://mangafox.la/manga/berserk/v01/c001/1.html", 3,
+		"http://l.mfcdn.net/store/manga/1/01-001.0/compressed/berserk_001_001.jpg")
+	if walk.Pages[2].URL.String() != "http://mangafox.la/manga/berserk/v01/c001/3.html" {
+		t.Errorf("got last page %s", walk.Pages[2].URL)
+	}
+}
+
+func TestScraper(t *testing.T) {
+	server := replay(t, "testdata/mangafox-definition.json")
+
+	suite := &backendtest.Suite{
+		Backend: &backends.Scraper{Definition: mangaFoxDefinition(t), Client: server.Client()},
+		Term:    "berserk",
+		Client:  server.Client(),
+	}
+	err := suite.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestScraperResolve(t *testing.T) {
+	server := replay(t, "testdata/mangafox-definition.json")
+	b := &backends.Scraper{Definition: mangaFoxDefinition(t), Client: server.Client()}
+
+	link, err := b.Resolve(mustParse(t, "https://www.mangafox.la/manga/berserk/v01/c001/2.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectLink(t, link, "Berserk", "Berserk 1", "http://mangafox.la/manga/berserk/v01/c001/2.html")
+
+	_, err = b.Resolve(mustParse(t, "http://mangafox.la/directory/"))
+	if !errors.Is(err, backends.ErrUnknownURL) {
+		t.Errorf("got %v, want ErrUnknownURL", err)
+	}
+}
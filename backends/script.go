@@ -0,0 +1,675 @@
+package backends
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/toxinu/katago/client"
+	"go.starlark.net/starlark"
+)
+
+// Script is a backend implemented by a Starlark script, the script is
+// reloaded whenever its file changes.
+//
+// A script defines a "name" string, an optional "limit" dict having "rate",
+// "burst" and "max_connections" keys, an optional "filters" dict having
+// Filters keys ("paging", "sorts", "include_genres", "exclude_genres",
+// "statuses", "author"), and the functions search(query), chapters(manga),
+// pages(chapter) and page_image_url(page). Queries are dicts having "term",
+// "page", "sort", "include_genres", "exclude_genres", "status" and "author"
+// keys, mangas dicts having "id", "name", "slug", "author", "genre" and "url"
+// keys, chapters dicts having "name" and "url" keys, pages and images are
+// URL strings.
+//
+// Scripts cannot load modules nor access files, they reach sites with the
+// builtins fetch(url), fetch_html(url) and fetch_json(url), and may use
+// resolve(base, url), quote(text) and match(pattern, text). Fetches only
+// take http and https URLs, and the backend client only follows redirects to
+// such URLs, see client.NewHTTPClient. A script load or call is stopped
+// after ScriptMaxSteps.
+type Script struct {
+	Slug   string
+	Path   string
+	Client *client.Client
+
+	mutex    sync.Mutex
+	modified time.Time
+	globals  starlark.StringDict
+}
+
+// ScriptMaxSteps is the number of Starlark computation steps a script load
+// or call may run
+var ScriptMaxSteps uint64 = 100000000
+
+// Thread locals of a call, its Client and the error of its last failed fetch
+const (
+	scriptClientKey = "client"
+	scriptErrorKey  = "error"
+)
+
+// LoadScripts loads every .star script of given directory, named after the
+// file without its extension, scripts failing to load are skipped and
+// returned as errors
+func LoadScripts(dir string) ([]*Script, []error) {
+	files, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, []error{err}
+	}
+
+	var errs []error
+	scripts := make([]*Script, 0, len(files))
+	for _, file := range files {
+		if file.IsDir() || filepath.Ext(file.Name()) != ".star" {
+			continue
+		}
+
+		s := &Script{
+			Slug: strings.TrimSuffix(file.Name(), ".star"),
+			Path: filepath.Join(dir, file.Name()),
+		}
+		_, err := s.load()
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		scripts = append(scripts, s)
+	}
+	return scripts, errs
+}
+
+// load returns the script globals, executing the script again when its file
+// changed since last load
+func (s *Script) load() (starlark.StringDict, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	info, err := os.Stat(s.Path)
+	if err != nil {
+		return nil, err
+	}
+	if s.globals != nil && info.ModTime().Equal(s.modified) {
+		return s.globals, nil
+	}
+
+	thread := newScriptThread(s.Slug)
+	globals, err := starlark.ExecFile(thread, s.Path, nil, scriptBuiltins)
+	if err != nil {
+		return nil, s.error(thread, err)
+	}
+	globals.Freeze()
+
+	for _, function := range []string{"search", "chapters", "pages", "page_image_url"} {
+		if _, ok := globals[function].(starlark.Callable); !ok {
+			return nil, fmt.Errorf("script %s: function %s is required", s.Slug, function)
+		}
+	}
+
+	s.globals = globals
+	s.modified = info.ModTime()
+	return globals, nil
+}
+
+// newScriptThread returns a thread running at most ScriptMaxSteps
+func newScriptThread(name string) *starlark.Thread {
+	thread := &starlark.Thread{Name: name}
+	thread.SetMaxExecutionSteps(ScriptMaxSteps)
+	return thread
+}
+
+// error wraps the failed fetch having caused err, if any, so it can still be
+// matched with errors.Is
+func (s *Script) error(thread *starlark.Thread, err error) error {
+	message := err.Error()
+	if evalErr, ok := err.(*starlark.EvalError); ok {
+		message = evalErr.Backtrace()
+	}
+
+	if fetchErr, ok := thread.Local(scriptErrorKey).(error); ok {
+		return fmt.Errorf("script %s: %w\n%s", s.Slug, fetchErr, message)
+	}
+	return fmt.Errorf("script %s: %s", s.Slug, message)
+}
+
+// call runs given script function, fetches are done with given client and
+// the call is cancelled once the client context is done
+func (s *Script) call(function string, c *client.Client, args ...starlark.Value) (starlark.Value, error) {
+	globals, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+
+	thread := newScriptThread(s.Slug)
+	thread.SetLocal(scriptClientKey, c)
+
+	ctx := c.Context()
+	if ctx.Done() != nil {
+		finished := make(chan struct{})
+		defer close(finished)
+		go func() {
+			select {
+			case <-ctx.Done():
+				thread.Cancel(ctx.Err().Error())
+			case <-finished:
+			}
+		}()
+	}
+
+	value, err := starlark.Call(thread, globals[function], args, nil)
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, fmt.Errorf("script %s: %s %w: %s", s.Slug, function, client.ErrCancelled, ctxErr)
+		}
+		return nil, s.error(thread, err)
+	}
+	return value, nil
+}
+
+// Limit returns the politeness rules declared by the script
+func (s *Script) Limit() client.Limit {
+	globals, err := s.load()
+	if err != nil {
+		return client.Limit{}
+	}
+
+	limit, ok := globals["limit"].(*starlark.Dict)
+	if !ok {
+		return client.Limit{}
+	}
+
+	var rate float64
+	if value, ok := starlark.AsFloat(dictGet(limit, "rate")); ok {
+		rate = value
+	}
+	var burst, maxConnections int
+	if value, err := starlark.AsInt32(dictGet(limit, "burst")); err == nil {
+		burst = value
+	}
+	if value, err := starlark.AsInt32(dictGet(limit, "max_connections")); err == nil {
+		maxConnections = value
+	}
+	return client.Limit{Rate: rate, Burst: burst, MaxConnections: maxConnections}
+}
+
+// Filters implements Filterer interface
+func (s *Script) Filters() Filters {
+	globals, err := s.load()
+	if err != nil {
+		return Filters{}
+	}
+
+	filters, ok := globals["filters"].(*starlark.Dict)
+	if !ok {
+		return Filters{}
+	}
+
+	return Filters{
+		Paging:        dictGet(filters, "paging").Truth() == starlark.True,
+		Sorts:         dictStrings(filters, "sorts"),
+		IncludeGenres: dictGet(filters, "include_genres").Truth() == starlark.True,
+		ExcludeGenres: dictGet(filters, "exclude_genres").Truth() == starlark.True,
+		Statuses:      dictStrings(filters, "statuses"),
+		Author:        dictGet(filters, "author").Truth() == starlark.True,
+	}
+}
+
+// Name implements Backend interface
+func (s *Script) Name() string {
+	globals, err := s.load()
+	if err != nil {
+		return s.Slug
+	}
+
+	name, ok := starlark.AsString(globals["name"])
+	if !ok || len(name) == 0 {
+		return s.Slug
+	}
+	return name
+}
+
+// Search implements Backend interface
+func (s *Script) Search(query *Query) ([]*Manga, error) {
+	dict := scriptDict(map[string]string{
+		"term":   query.Term,
+		"sort":   query.Sort,
+		"status": query.Status,
+		"author": query.Author,
+	})
+	dict.SetKey(starlark.String("page"), starlark.MakeInt(query.Page))
+	dict.SetKey(starlark.String("include_genres"), scriptList(query.IncludeGenres))
+	dict.SetKey(starlark.String("exclude_genres"), scriptList(query.ExcludeGenres))
+
+	value, err := s.call("search", s.Client.For(client.RequestSearch).WithContext(query.Context()), dict)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := s.dicts("search", value)
+	if err != nil {
+		return nil, err
+	}
+
+	mangas := make([]*Manga, 0, len(items))
+	for _, item := range items {
+		mangaURL, err := s.parseURL(dictString(item, "url"))
+		if err != nil {
+			return nil, err
+		}
+		mangas = append(mangas, &Manga{
+			ID:     dictString(item, "id"),
+			Name:   dictString(item, "name"),
+			Slug:   dictString(item, "slug"),
+			Author: dictString(item, "author"),
+			Genre:  dictString(item, "genre"),
+			URL:    mangaURL,
+		})
+	}
+	return mangas, nil
+}
+
+// Chapters implements Backend interface
+func (s *Script) Chapters(manga *Manga) ([]*Chapter, error) {
+	value, err := s.call("chapters", s.Client.For(client.RequestChapters), scriptDict(map[string]string{
+		"id":     manga.ID,
+		"name":   manga.Name,
+		"slug":   manga.Slug,
+		"author": manga.Author,
+		"genre":  manga.Genre,
+		"url":    manga.URL.String(),
+	}))
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := s.dicts("chapters", value)
+	if err != nil {
+		return nil, err
+	}
+
+	chapters := make([]*Chapter, 0, len(items))
+	for _, item := range items {
+		chapterURL, err := s.parseURL(dictString(item, "url"))
+		if err != nil {
+			return nil, err
+		}
+		chapters = append(chapters, &Chapter{Name: dictString(item, "name"), URL: chapterURL})
+	}
+	return chapters, nil
+}
+
+// Pages implements Backend interface
+func (s *Script) Pages(chapter *Chapter) ([]*Page, error) {
+	value, err := s.call("pages", s.Client.For(client.RequestPages), scriptDict(map[string]string{
+		"name": chapter.Name,
+		"url":  chapter.URL.String(),
+	}))
+	if err != nil {
+		return nil, err
+	}
+
+	list, ok := value.(*starlark.List)
+	if !ok {
+		return nil, fmt.Errorf("script %s: pages returned %s instead of a list", s.Slug, value.Type())
+	}
+
+	pages := make([]*Page, 0, list.Len())
+	for i := 0; i < list.Len(); i++ {
+		raw, _ := starlark.AsString(list.Index(i))
+		pageURL, err := s.parseURL(raw)
+		if err != nil {
+			return nil, err
+		}
+		pages = append(pages, &Page{URL: pageURL})
+	}
+	return pages, nil
+}
+
+// PageImageURL implements Backend interface
+func (s *Script) PageImageURL(page *Page) (*url.URL, error) {
+	value, err := s.call("page_image_url", s.Client.For(client.RequestPage), starlark.String(page.URL.String()))
+	if err != nil {
+		return nil, err
+	}
+
+	raw, _ := starlark.AsString(value)
+	return s.parseURL(raw)
+}
+
+// dicts checks a script function returned a list of dicts
+func (s *Script) dicts(function string, value starlark.Value) ([]*starlark.Dict, error) {
+	list, ok := value.(*starlark.List)
+	if !ok {
+		return nil, fmt.Errorf("script %s: %s returned %s instead of a list", s.Slug, function, value.Type())
+	}
+
+	dicts := make([]*starlark.Dict, 0, list.Len())
+	for i := 0; i < list.Len(); i++ {
+		dict, ok := list.Index(i).(*starlark.Dict)
+		if !ok {
+			return nil, fmt.Errorf("script %s: %s returned a list of %s instead of dicts", s.Slug, function, list.Index(i).Type())
+		}
+		dicts = append(dicts, dict)
+	}
+	return dicts, nil
+}
+
+func (s *Script) parseURL(raw string) (*url.URL, error) {
+	u, err := url.Parse(raw)
+	if err != nil || !u.IsAbs() {
+		if err == nil {
+			err = errors.New("URL is not absolute")
+		}
+		return nil, fmt.Errorf("script %s: invalid URL '%s': %s", s.Slug, raw, err)
+	}
+	return u, nil
+}
+
+func dictGet(dict *starlark.Dict, key string) starlark.Value {
+	value, found, _ := dict.Get(starlark.String(key))
+	if !found {
+		return starlark.None
+	}
+	return value
+}
+
+func dictString(dict *starlark.Dict, key string) string {
+	value, _ := starlark.AsString(dictGet(dict, key))
+	return value
+}
+
+func dictStrings(dict *starlark.Dict, key string) []string {
+	list, ok := dictGet(dict, key).(*starlark.List)
+	if !ok {
+		return nil
+	}
+
+	values := make([]string, 0, list.Len())
+	for i := 0; i < list.Len(); i++ {
+		if value, ok := starlark.AsString(list.Index(i)); ok {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+func scriptList(values []string) *starlark.List {
+	list := make([]starlark.Value, 0, len(values))
+	for _, value := range values {
+		list = append(list, starlark.String(value))
+	}
+	return starlark.NewList(list)
+}
+
+func scriptDict(values map[string]string) *starlark.Dict {
+	dict := starlark.NewDict(len(values))
+	for key, value := range values {
+		dict.SetKey(starlark.String(key), starlark.String(value))
+	}
+	return dict
+}
+
+// scriptBuiltins are the builtins available to scripts
+var scriptBuiltins = starlark.StringDict{
+	"fetch":      starlark.NewBuiltin("fetch", scriptFetch),
+	"fetch_html": starlark.NewBuiltin("fetch_html", scriptFetch),
+	"fetch_json": starlark.NewBuiltin("fetch_json", scriptFetch),
+	"resolve":    starlark.NewBuiltin("resolve", scriptResolve),
+	"quote":      starlark.NewBuiltin("quote", scriptQuote),
+	"match":      starlark.NewBuiltin("match", scriptMatch),
+}
+
+// scriptFetch gets an http or https URL with the Client of the call, and
+// returns its body as a string, a selection or decoded JSON
+func scriptFetch(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var raw string
+	err := starlark.UnpackPositionalArgs(fn.Name(), args, kwargs, 1, &raw)
+	if err != nil {
+		return nil, err
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("%s: only http and https URLs can be fetched", fn.Name())
+	}
+
+	c, ok := thread.Local(scriptClientKey).(*client.Client)
+	if !ok {
+		return nil, fmt.Errorf("%s: fetching is not allowed at load time", fn.Name())
+	}
+
+	resp, err := c.Get(u, []int{200})
+	if err != nil {
+		thread.SetLocal(scriptErrorKey, err)
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch fn.Name() {
+	case "fetch_html":
+		doc, err := goquery.NewDocumentFromReader(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+		return &scriptSelection{doc.Selection}, nil
+	case "fetch_json":
+		var value interface{}
+		err = json.NewDecoder(resp.Body).Decode(&value)
+		if err != nil {
+			return nil, err
+		}
+		return scriptValue(value), nil
+	default:
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+		return starlark.String(body), nil
+	}
+}
+
+func scriptResolve(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var base, ref string
+	err := starlark.UnpackPositionalArgs(fn.Name(), args, kwargs, 2, &base, &ref)
+	if err != nil {
+		return nil, err
+	}
+
+	baseURL, err := url.Parse(base)
+	if err != nil {
+		return nil, err
+	}
+	refURL, err := url.Parse(strings.TrimSpace(ref))
+	if err != nil {
+		return nil, err
+	}
+	return starlark.String(baseURL.ResolveReference(refURL).String()), nil
+}
+
+func scriptQuote(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var text string
+	err := starlark.UnpackPositionalArgs(fn.Name(), args, kwargs, 1, &text)
+	if err != nil {
+		return nil, err
+	}
+	return starlark.String(url.QueryEscape(text)), nil
+}
+
+// scriptMatch returns the first group of pattern in text, the whole match
+// when pattern has no group, or None
+func scriptMatch(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var pattern, text string
+	err := starlark.UnpackPositionalArgs(fn.Name(), args, kwargs, 2, &pattern, &text)
+	if err != nil {
+		return nil, err
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	matches := re.FindStringSubmatch(text)
+	switch len(matches) {
+	case 0:
+		return starlark.None, nil
+	case 1:
+		return starlark.String(matches[0]), nil
+	default:
+		return starlark.String(matches[1]), nil
+	}
+}
+
+// scriptValue converts decoded JSON to a Starlark value
+func scriptValue(value interface{}) starlark.Value {
+	switch v := value.(type) {
+	case string:
+		return starlark.String(v)
+	case bool:
+		return starlark.Bool(v)
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < 1<<53 {
+			return starlark.MakeInt64(int64(v))
+		}
+		return starlark.Float(v)
+	case []interface{}:
+		list := make([]starlark.Value, 0, len(v))
+		for _, item := range v {
+			list = append(list, scriptValue(item))
+		}
+		return starlark.NewList(list)
+	case map[string]interface{}:
+		dict := starlark.NewDict(len(v))
+		for key, item := range v {
+			dict.SetKey(starlark.String(key), scriptValue(item))
+		}
+		return dict
+	default:
+		return starlark.None
+	}
+}
+
+// scriptSelection exposes a goquery selection to scripts, it can be indexed
+// and iterated and has find, first, text, attr and html methods
+type scriptSelection struct {
+	selection *goquery.Selection
+}
+
+var scriptSelectionMethods = map[string]*starlark.Builtin{
+	"find":  starlark.NewBuiltin("find", scriptSelectionFind),
+	"first": starlark.NewBuiltin("first", scriptSelectionFirst),
+	"text":  starlark.NewBuiltin("text", scriptSelectionText),
+	"attr":  starlark.NewBuiltin("attr", scriptSelectionAttr),
+	"html":  starlark.NewBuiltin("html", scriptSelectionHTML),
+}
+
+func (s *scriptSelection) String() string        { return fmt.Sprintf("<selection of %d>", s.Len()) }
+func (s *scriptSelection) Type() string          { return "selection" }
+func (s *scriptSelection) Freeze()               {}
+func (s *scriptSelection) Truth() starlark.Bool  { return s.Len() > 0 }
+func (s *scriptSelection) Hash() (uint32, error) { return 0, errors.New("unhashable type: selection") }
+func (s *scriptSelection) Len() int              { return s.selection.Length() }
+
+func (s *scriptSelection) Index(i int) starlark.Value {
+	return &scriptSelection{s.selection.Eq(i)}
+}
+
+func (s *scriptSelection) Iterate() starlark.Iterator {
+	return &scriptSelectionIterator{selection: s}
+}
+
+func (s *scriptSelection) Attr(name string) (starlark.Value, error) {
+	method, ok := scriptSelectionMethods[name]
+	if !ok {
+		return nil, nil
+	}
+	return method.BindReceiver(s), nil
+}
+
+func (s *scriptSelection) AttrNames() []string {
+	return []string{"attr", "find", "first", "html", "text"}
+}
+
+type scriptSelectionIterator struct {
+	selection *scriptSelection
+	index     int
+}
+
+func (it *scriptSelectionIterator) Next(p *starlark.Value) bool {
+	if it.index >= it.selection.Len() {
+		return false
+	}
+	*p = it.selection.Index(it.index)
+	it.index++
+	return true
+}
+
+func (it *scriptSelectionIterator) Done() {}
+
+func scriptSelectionFind(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var selector string
+	err := starlark.UnpackPositionalArgs(fn.Name(), args, kwargs, 1, &selector)
+	if err != nil {
+		return nil, err
+	}
+	return &scriptSelection{fn.Receiver().(*scriptSelection).selection.Find(selector)}, nil
+}
+
+func scriptSelectionFirst(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	err := starlark.UnpackPositionalArgs(fn.Name(), args, kwargs, 0)
+	if err != nil {
+		return nil, err
+	}
+	return &scriptSelection{fn.Receiver().(*scriptSelection).selection.First()}, nil
+}
+
+func scriptSelectionText(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	err := starlark.UnpackPositionalArgs(fn.Name(), args, kwargs, 0)
+	if err != nil {
+		return nil, err
+	}
+	return starlark.String(strings.TrimSpace(fn.Receiver().(*scriptSelection).selection.Text())), nil
+}
+
+// scriptSelectionAttr returns the attribute of the first node, or None
+func scriptSelectionAttr(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var name string
+	err := starlark.UnpackPositionalArgs(fn.Name(), args, kwargs, 1, &name)
+	if err != nil {
+		return nil, err
+	}
+
+	value, ok := fn.Receiver().(*scriptSelection).selection.Attr(name)
+	if !ok {
+		return starlark.None, nil
+	}
+	return starlark.String(strings.TrimSpace(value)), nil
+}
+
+func scriptSelectionHTML(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	err := starlark.UnpackPositionalArgs(fn.Name(), args, kwargs, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	html, err := fn.Receiver().(*scriptSelection).selection.Html()
+	if err != nil {
+		return nil, err
+	}
+	return starlark.String(html), nil
+}
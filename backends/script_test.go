@@ -0,0 +1,72 @@
+package backends_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/toxinu/katago/backends"
+	"github.com/toxinu/katago/backends/backendtest"
+	"github.com/toxinu/katago/client"
+)
+
+func TestScript(t *testing.T) {
+	server := replay(t, "testdata/mangafox.json")
+
+	script := &backends.Script{Slug: "mangafox-script", Path: "../scripts/mangafox-script.star", Client: server.Client()}
+	suite := &backendtest.Suite{Backend: script, Term: "berserk", Client: server.Client()}
+	err := suite.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestScriptStopsRunawayCalls(t *testing.T) {
+	dir, err := ioutil.TempDir("", "katago-scripts-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "runaway.star")
+	err = ioutil.WriteFile(path, []byte(`name = "Runaway"
+
+def search(query):
+    for i in range(1 << 62):
+        pass
+
+def chapters(manga):
+    return []
+
+def pages(chapter):
+    return []
+
+def page_image_url(page):
+    return page
+`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	maxSteps := backends.ScriptMaxSteps
+	backends.ScriptMaxSteps = 100000
+	defer func() { backends.ScriptMaxSteps = maxSteps }()
+
+	done := make(chan error)
+	go func() {
+		_, err := (&backends.Script{Slug: "runaway", Path: path, Client: client.NewClient()}).Search(backends.NewQuery("berserk"))
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if err == nil || !strings.Contains(err.Error(), "too many steps") {
+			t.Errorf("got %v, want the call to run out of steps", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("runaway call was not stopped")
+	}
+}
@@ -61,3 +61,12 @@ func chapterSliceReverse(chapters []*Chapter) []*Chapter {
 	}
 	return reversed
 }
+
+func stringSliceContains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
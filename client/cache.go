@@ -0,0 +1,286 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RequestType tells what a request is for, Cache uses it to pick a TTL
+type RequestType string
+
+// Request types
+const (
+	RequestSearch   RequestType = "search"
+	RequestLatest   RequestType = "latest"
+	RequestChapters RequestType = "chapters"
+	RequestPages    RequestType = "pages"
+	RequestPage     RequestType = "page"
+	RequestImage    RequestType = "image"
+)
+
+// DefaultCacheTTLs is the TTLs used by NewCache, images are not cached
+var DefaultCacheTTLs = map[RequestType]time.Duration{
+	RequestSearch:   time.Hour,
+	RequestLatest:   5 * time.Minute,
+	RequestChapters: 30 * time.Minute,
+	RequestPages:    24 * time.Hour,
+	RequestPage:     6 * time.Hour,
+}
+
+// Cache stores responses on disk
+type Cache struct {
+	Dir string
+	// MaxSize is the cache size in bytes, zero means unlimited
+	MaxSize int64
+	TTLs    map[RequestType]time.Duration
+
+	mutex sync.Mutex
+	// size is the cache size once sized, when the directory was read
+	size  int64
+	sized bool
+}
+
+// NewCache returns a new Cache
+func NewCache(dir string, maxSize int64) *Cache {
+	ttls := make(map[RequestType]time.Duration, len(DefaultCacheTTLs))
+	for requestType, ttl := range DefaultCacheTTLs {
+		ttls[requestType] = ttl
+	}
+	return &Cache{Dir: dir, MaxSize: maxSize, TTLs: ttls}
+}
+
+// TTL returns how long given request type responses are fresh
+func (c *Cache) TTL(requestType RequestType) time.Duration {
+	if c == nil {
+		return 0
+	}
+	return c.TTLs[requestType]
+}
+
+type cacheEntry struct {
+	URL          string      `json:"url"`
+	Header       http.Header `json:"header"`
+	Stored       time.Time   `json:"stored"`
+	ETag         string      `json:"etag"`
+	LastModified string      `json:"last_modified"`
+
+	body []byte
+}
+
+func (e *cacheEntry) fresh(ttl time.Duration) bool {
+	return time.Since(e.Stored) < ttl
+}
+
+func (e *cacheEntry) revalidate(req *http.Request) bool {
+	if len(e.ETag) > 0 {
+		req.Header.Set("If-None-Match", e.ETag)
+	}
+	if len(e.LastModified) > 0 {
+		req.Header.Set("If-Modified-Since", e.LastModified)
+	}
+	return len(e.ETag) > 0 || len(e.LastModified) > 0
+}
+
+func (e *cacheEntry) response(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        e.Header,
+		Body:          ioutil.NopCloser(bytes.NewReader(e.body)),
+		ContentLength: int64(len(e.body)),
+		Request:       req,
+	}
+}
+
+func (c *Cache) path(u *url.URL) string {
+	sum := sha256.Sum256([]byte(u.String()))
+	return filepath.Join(c.Dir, hex.EncodeToString(sum[:]))
+}
+
+// load returns given URL entry, or nil if it is not cached
+func (c *Cache) load(u *url.URL) *cacheEntry {
+	data, err := ioutil.ReadFile(c.path(u))
+	if err != nil {
+		return nil
+	}
+
+	index := bytes.IndexByte(data, '\n')
+	if index < 0 {
+		return nil
+	}
+	header, body := data[:index], data[index+1:]
+
+	entry := &cacheEntry{}
+	if json.Unmarshal(header, entry) != nil || entry.URL != u.String() {
+		return nil
+	}
+	entry.body = body
+
+	now := time.Now()
+	os.Chtimes(c.path(u), now, now)
+
+	return entry
+}
+
+// store saves a successful response and returns a copy of it
+func (c *Cache) store(u *url.URL, resp *http.Response) (*http.Response, error) {
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := &cacheEntry{
+		URL:          u.String(),
+		Header:       resp.Header,
+		Stored:       time.Now(),
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		body:         body,
+	}
+
+	// cache is best effort, a failed write must not fail the request
+	c.write(u, entry)
+
+	return entry.response(resp.Request), nil
+}
+
+// touch marks an entry as fresh after a successful revalidation
+func (c *Cache) touch(u *url.URL, entry *cacheEntry) error {
+	entry.Stored = time.Now()
+	return c.write(u, entry)
+}
+
+func (c *Cache) write(u *url.URL, entry *cacheEntry) error {
+	header, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(c.Dir, 0755)
+	if err != nil {
+		return err
+	}
+
+	file, err := ioutil.TempFile(c.Dir, ".tmp-")
+	if err != nil {
+		return err
+	}
+
+	writer := bufio.NewWriter(file)
+	writer.Write(header)
+	writer.WriteString("\n")
+	writer.Write(entry.body)
+	err = writer.Flush()
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(file.Name())
+		return err
+	}
+
+	replaced := fileSize(c.path(u))
+	err = os.Rename(file.Name(), c.path(u))
+	if err != nil {
+		os.Remove(file.Name())
+		return err
+	}
+
+	return c.grow(int64(len(header)+1+len(entry.body)) - replaced)
+}
+
+// Delete removes given URL entry
+func (c *Cache) Delete(u *url.URL) error {
+	removed := fileSize(c.path(u))
+	err := os.Remove(c.path(u))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return c.grow(-removed)
+}
+
+func fileSize(name string) int64 {
+	info, err := os.Stat(name)
+	if err != nil {
+		return 0
+	}
+	return info.Size()
+}
+
+// grow adds given bytes to the cache size, the directory is only read to
+// know its size once, and to evict entries once over MaxSize
+func (c *Cache) grow(bytes int64) error {
+	if c.MaxSize <= 0 {
+		return nil
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.sized {
+		c.size += bytes
+		if c.size <= c.MaxSize {
+			return nil
+		}
+	}
+	return c.evict()
+}
+
+// evict removes least recently used entries until cache fits MaxSize, the
+// mutex must be held
+func (c *Cache) evict() error {
+	files, err := ioutil.ReadDir(c.Dir)
+	if err != nil {
+		return err
+	}
+
+	var size int64
+	for _, file := range files {
+		size += file.Size()
+	}
+	c.size, c.sized = size, true
+	if size <= c.MaxSize {
+		return nil
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].ModTime().Before(files[j].ModTime())
+	})
+
+	for _, file := range files {
+		if size <= c.MaxSize {
+			break
+		}
+		if file.IsDir() || strings.HasPrefix(file.Name(), ".tmp-") {
+			continue
+		}
+		err = os.Remove(filepath.Join(c.Dir, file.Name()))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		size -= file.Size()
+		c.size = size
+	}
+
+	return nil
+}
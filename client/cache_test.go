@@ -0,0 +1,202 @@
+package client
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// cacheServer answers "<path> <n>" with n the number of requests to path,
+// and 304 to requests revalidating a path with ETag or Last-Modified
+type cacheServer struct {
+	*httptest.Server
+
+	mutex    sync.Mutex
+	requests map[string]int
+}
+
+const lastModified = "Mon, 02 Jan 2006 15:04:05 GMT"
+
+func newCacheServer() *cacheServer {
+	s := &cacheServer{requests: map[string]int{}}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mutex.Lock()
+		s.requests[r.URL.Path]++
+		count := s.requests[r.URL.Path]
+		s.mutex.Unlock()
+
+		switch r.URL.Path {
+		case "/etag":
+			w.Header().Set("ETag", `"v1"`)
+			if r.Header.Get("If-None-Match") == `"v1"` {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+		case "/last-modified":
+			w.Header().Set("Last-Modified", lastModified)
+			if r.Header.Get("If-Modified-Since") == lastModified {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+		}
+		w.Write([]byte(r.URL.Path + " " + strings.Repeat("x", count)))
+	}))
+	return s
+}
+
+func (s *cacheServer) count(path string) int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.requests[path]
+}
+
+func newCacheClient(t *testing.T, maxSize int64) (*Client, *Cache) {
+	dir, err := ioutil.TempDir("", "katago-cache-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	c := NewClient()
+	c.Limiter = nil
+	c.Retry = RetryPolicy{MaxAttempts: 1}
+	c.Cache = NewCache(dir, maxSize)
+	return c, c.Cache
+}
+
+func get(t *testing.T, c *Client, rawURL string) string {
+	u, _ := url.Parse(rawURL)
+	resp, err := c.Get(u, []int{http.StatusOK})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(body)
+}
+
+func TestCacheTTLs(t *testing.T) {
+	server := newCacheServer()
+	defer server.Close()
+	c, cache := newCacheClient(t, 0)
+	cache.TTLs[RequestChapters] = 50 * time.Millisecond
+
+	search := c.For(RequestSearch)
+	first := get(t, search, server.URL+"/search")
+	if second := get(t, search, server.URL+"/search"); second != first || server.count("/search") != 1 {
+		t.Errorf("fresh search requested again, got %q after %q", second, first)
+	}
+
+	// Images have no TTL, they are never cached
+	images := c.For(RequestImage)
+	get(t, images, server.URL+"/image")
+	get(t, images, server.URL+"/image")
+	if count := server.count("/image"); count != 2 {
+		t.Errorf("image requested %d times, want 2", count)
+	}
+
+	chapters := c.For(RequestChapters)
+	get(t, chapters, server.URL+"/chapters")
+	get(t, chapters, server.URL+"/chapters")
+	time.Sleep(60 * time.Millisecond)
+	if body := get(t, chapters, server.URL+"/chapters"); body != "/chapters xx" {
+		t.Errorf("stale chapters not requested again, got %q", body)
+	}
+	if count := server.count("/chapters"); count != 2 {
+		t.Errorf("chapters requested %d times, want 2", count)
+	}
+}
+
+func TestCacheRevalidation(t *testing.T) {
+	server := newCacheServer()
+	defer server.Close()
+	c, cache := newCacheClient(t, 0)
+	cache.TTLs[RequestPage] = time.Nanosecond
+	page := c.For(RequestPage)
+
+	for _, path := range []string{"/etag", "/last-modified"} {
+		first := get(t, page, server.URL+path)
+		time.Sleep(time.Millisecond)
+		// The server answers 304, the cached body is served
+		if second := get(t, page, server.URL+path); second != first {
+			t.Errorf("%s: got %q after revalidation, want %q", path, second, first)
+		}
+		if count := server.count(path); count != 2 {
+			t.Errorf("%s: requested %d times, want 2", path, count)
+		}
+	}
+
+	// Without validators a stale entry is requested again in full
+	get(t, page, server.URL+"/plain")
+	time.Sleep(time.Millisecond)
+	if body := get(t, page, server.URL+"/plain"); body != "/plain xx" {
+		t.Errorf("got %q, want the new response", body)
+	}
+}
+
+func TestCacheEviction(t *testing.T) {
+	server := newCacheServer()
+	defer server.Close()
+	c, cache := newCacheClient(t, 0)
+	search := c.For(RequestSearch)
+
+	// Learn the size of an entry to fit two of them
+	get(t, search, server.URL+"/a")
+	files, _ := ioutil.ReadDir(cache.Dir)
+	cache.MaxSize = 2*files[0].Size() + files[0].Size()/2
+
+	get(t, search, server.URL+"/b")
+	time.Sleep(10 * time.Millisecond)
+	get(t, search, server.URL+"/a")
+	get(t, search, server.URL+"/c")
+
+	// /b is the least recently used entry
+	for _, test := range []struct {
+		path string
+		want int
+	}{{"/a", 1}, {"/c", 1}, {"/b", 2}} {
+		get(t, search, server.URL+test.path)
+		if count := server.count(test.path); count != test.want {
+			t.Errorf("%s requested %d times, want %d", test.path, count, test.want)
+		}
+	}
+
+	files, _ = ioutil.ReadDir(cache.Dir)
+	var size int64
+	for _, file := range files {
+		size += file.Size()
+	}
+	if size > cache.MaxSize {
+		t.Errorf("cache is %d bytes, over %d", size, cache.MaxSize)
+	}
+}
+
+func TestCacheSkipsRequestsWithCookies(t *testing.T) {
+	server := newCacheServer()
+	defer server.Close()
+	c, _ := newCacheClient(t, 0)
+	search := c.For(RequestSearch)
+
+	jar, _ := cookiejar.New(nil)
+	u, _ := url.Parse(server.URL)
+	jar.SetCookies(u, []*http.Cookie{{Name: "session", Value: "alice"}})
+	logged := search.WithCookieJar(jar)
+
+	get(t, search, server.URL+"/search")
+	if body := get(t, logged, server.URL+"/search"); body != "/search xx" {
+		t.Errorf("logged in request served %q from cache", body)
+	}
+	if body := get(t, search, server.URL+"/search"); body != "/search x" {
+		t.Errorf("logged in response cached, got %q", body)
+	}
+}
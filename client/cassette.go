@@ -0,0 +1,230 @@
+package client
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Cassette holds recorded HTTP interactions
+type Cassette struct {
+	Interactions []*Interaction `json:"interactions"`
+
+	mutex   sync.Mutex
+	replays map[string]int
+}
+
+// Interaction represents a recorded request and its response
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// RecordedRequest represents a recorded request
+type RecordedRequest struct {
+	Method string `json:"method"`
+	URL    string `json:"url"`
+}
+
+// RecordedResponse represents a recorded response
+type RecordedResponse struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header"`
+	Body       string      `json:"body"`
+	// Encoding is "base64" when Body is not valid UTF-8
+	Encoding string `json:"encoding,omitempty"`
+}
+
+// scrubbedHeaders are the credentials headers never written in a cassette
+var scrubbedHeaders = []string{"Set-Cookie", "Cookie", "Authorization"}
+
+// NewCassette returns an empty Cassette
+func NewCassette() *Cassette {
+	return &Cassette{}
+}
+
+// LoadCassette reads a Cassette file
+func LoadCassette(path string) (*Cassette, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	cassette := NewCassette()
+	err = json.Unmarshal(data, cassette)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cassette %s: %s", path, err)
+	}
+	return cassette, nil
+}
+
+// Save writes Cassette to given path
+func (c *Cassette) Save(path string) error {
+	c.mutex.Lock()
+	data, err := json.MarshalIndent(c, "", "  ")
+	c.mutex.Unlock()
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0644)
+}
+
+// Add records a response, its body is read and replaced by a copy
+func (c *Cassette) Add(resp *http.Response) error {
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	header := resp.Header.Clone()
+	for _, name := range scrubbedHeaders {
+		header.Del(name)
+	}
+
+	recorded := RecordedResponse{StatusCode: resp.StatusCode, Header: header, Body: string(body)}
+	if !utf8.Valid(body) {
+		recorded.Body = base64.StdEncoding.EncodeToString(body)
+		recorded.Encoding = "base64"
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.Interactions = append(c.Interactions, &Interaction{
+		Request:  RecordedRequest{Method: resp.Request.Method, URL: resp.Request.URL.String()},
+		Response: recorded,
+	})
+	return nil
+}
+
+// Find returns the interaction recorded for given request, requests sent
+// several times replay their interactions in order and then the last one
+func (c *Cassette) Find(method string, url string) (*Interaction, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	var matches []*Interaction
+	for _, interaction := range c.Interactions {
+		if strings.EqualFold(interaction.Request.Method, method) && interaction.Request.URL == url {
+			matches = append(matches, interaction)
+		}
+	}
+	if len(matches) == 0 {
+		return nil, false
+	}
+
+	if c.replays == nil {
+		c.replays = map[string]int{}
+	}
+	key := method + " " + url
+	index := c.replays[key]
+	if index >= len(matches) {
+		index = len(matches) - 1
+	}
+	c.replays[key]++
+
+	return matches[index], true
+}
+
+// Bytes returns recorded response body
+func (r *RecordedResponse) Bytes() ([]byte, error) {
+	if r.Encoding == "base64" {
+		return base64.StdEncoding.DecodeString(r.Body)
+	}
+	return []byte(r.Body), nil
+}
+
+// Replay returns recorded response for given request
+func (i *Interaction) Replay(req *http.Request) (*http.Response, error) {
+	body, err := i.Response.Bytes()
+	if err != nil {
+		return nil, err
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", i.Response.StatusCode, http.StatusText(i.Response.StatusCode)),
+		StatusCode:    i.Response.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        i.Response.Header.Clone(),
+		Body:          ioutil.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+// RecorderMode tells whether a Recorder records or replays interactions
+type RecorderMode int
+
+// Recorder modes
+const (
+	// ModeRecord sends requests and records their responses
+	ModeRecord RecorderMode = iota
+	// ModeReplay answers requests from recorded responses only
+	ModeReplay
+)
+
+// Recorder is an http.RoundTripper recording or replaying a Cassette
+type Recorder struct {
+	Cassette *Cassette
+	Mode     RecorderMode
+	// Transport sends requests in ModeRecord
+	Transport http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper interface
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	if r.Mode == ModeReplay {
+		interaction, ok := r.Cassette.Find(req.Method, req.URL.String())
+		if !ok {
+			return nil, fmt.Errorf("no recorded interaction for %s %s", req.Method, req.URL)
+		}
+		return interaction.Replay(req)
+	}
+
+	transport := r.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.Cassette.Add(resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// WithRecorder returns a copy of Client whose requests go through given
+// Recorder, it records with Client's transport unless one is set
+func (c *Client) WithRecorder(recorder *Recorder) *Client {
+	httpClient := *c.httpClient()
+	if recorder.Transport == nil {
+		recorder.Transport = httpClient.Transport
+	}
+	httpClient.Transport = recorder
+
+	clientCopy := *c
+	clientCopy.HTTPClient = &httpClient
+	return &clientCopy
+}
@@ -0,0 +1,44 @@
+package client
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestCassetteScrubsCredentials(t *testing.T) {
+	u, _ := url.Parse("http://example.com/login")
+	resp := &http.Response{
+		StatusCode: http.StatusOK,
+		Header: http.Header{
+			"Set-Cookie":    {"session=secret"},
+			"Authorization": {"Bearer secret"},
+			"Cookie":        {"session=secret"},
+			"Content-Type":  {"text/html"},
+		},
+		Body:    ioutil.NopCloser(strings.NewReader("welcome")),
+		Request: &http.Request{Method: http.MethodGet, URL: u},
+	}
+
+	cassette := NewCassette()
+	err := cassette.Add(resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	header := cassette.Interactions[0].Response.Header
+	for _, name := range []string{"Set-Cookie", "Authorization", "Cookie"} {
+		if len(header.Get(name)) > 0 {
+			t.Errorf("%s header was recorded", name)
+		}
+	}
+	if header.Get("Content-Type") != "text/html" {
+		t.Error("Content-Type header was not recorded")
+	}
+	// Caller still gets the cookies to store in its jar
+	if resp.Header.Get("Set-Cookie") != "session=secret" {
+		t.Error("response Set-Cookie header was removed")
+	}
+}
@@ -1,7 +1,7 @@
 package client
 
 import (
-	"fmt"
+	"context"
 	"net/http"
 	"net/url"
 	"time"
@@ -12,12 +12,86 @@ import (
 
 // Client represents an HTTP client
 type Client struct {
-	Retry int
+	Retry      RetryPolicy
+	Limit      Limit
+	Limiter    *Limiter
+	Cache      *Cache
+	Transport  TransportOptions
+	HTTPClient *http.Client
+
+	ctx         context.Context
+	requestType RequestType
 }
 
 // NewClient returns a new Client
 func NewClient() *Client {
-	return &Client{}
+	// default transport options have neither proxy nor CA bundle to load
+	httpClient, _ := NewHTTPClient(DefaultTransportOptions)
+
+	return &Client{
+		Retry:      DefaultRetryPolicy,
+		Limiter:    DefaultLimiter,
+		Transport:  DefaultTransportOptions,
+		HTTPClient: httpClient,
+	}
+}
+
+// WithTransport returns a copy of Client using given transport options
+func (c *Client) WithTransport(options TransportOptions) (*Client, error) {
+	httpClient, err := NewHTTPClient(options)
+	if err != nil {
+		return nil, err
+	}
+	if c.HTTPClient != nil {
+		httpClient.Jar = c.HTTPClient.Jar
+	}
+
+	clientCopy := *c
+	clientCopy.Transport = options
+	clientCopy.HTTPClient = httpClient
+	return &clientCopy, nil
+}
+
+// WithContext returns a copy of Client whose requests are bound to given
+// Context, they fail with ErrCancelled once it is done
+func (c *Client) WithContext(ctx context.Context) *Client {
+	clientCopy := *c
+	clientCopy.ctx = ctx
+	return &clientCopy
+}
+
+// Context returns Client's Context
+func (c *Client) Context() context.Context {
+	if c.ctx == nil {
+		return context.Background()
+	}
+	return c.ctx
+}
+
+// For returns a copy of Client whose requests are of given type
+func (c *Client) For(requestType RequestType) *Client {
+	clientCopy := *c
+	clientCopy.requestType = requestType
+	return &clientCopy
+}
+
+// WithLimit returns a copy of Client using given Limit
+func (c *Client) WithLimit(limit Limit) *Client {
+	clientCopy := *c
+	clientCopy.Limit = limit
+	return &clientCopy
+}
+
+func (c *Client) httpClient() *http.Client {
+	if c.HTTPClient == nil {
+		return http.DefaultClient
+	}
+	return c.HTTPClient
+}
+
+func (c *Client) sendsCookies(u *url.URL) bool {
+	jar := c.httpClient().Jar
+	return jar != nil && len(jar.Cookies(u)) > 0
 }
 
 func (*Client) contains(intSlice []int, searchInt int) bool {
@@ -29,30 +103,112 @@ func (*Client) contains(intSlice []int, searchInt int) bool {
 	return false
 }
 
+func (c *Client) success(successCodes []int, statusCode int) bool {
+	return c.contains(successCodes, 0) || c.contains(successCodes, statusCode)
+}
+
 // Get send a GET request
 func (c *Client) Get(u *url.URL, successCodes []int) (*http.Response, error) {
 	var (
-		err  error
-		req  *http.Request
-		resp *http.Response
+		err   error
+		req   *http.Request
+		resp  *http.Response
+		entry *cacheEntry
+		ttl   = c.Cache.TTL(c.requestType)
 	)
 
 	req, err = http.NewRequest("GET", u.String(), nil)
 	if err != nil {
 		return nil, err
 	}
+	req = req.WithContext(c.Context())
 
 	req.Header.Add("User-Agent", "Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/31.0.1650.4 Safari/537.36")
 
-	for i := 0; i < c.Retry; i++ {
-		resp, err = http.DefaultClient.Do(req)
-		if err == nil && (len(successCodes) == 1 && (successCodes[0] == 0 || c.contains(successCodes, resp.StatusCode))) {
-			return resp, err
+	// Responses to requests sending cookies may be specific to a login,
+	// they are not cached
+	if ttl <= 0 || u.Scheme == "file" || c.sendsCookies(u) {
+		return c.do(req, successCodes)
+	}
+
+	entry = c.Cache.load(u)
+	if entry != nil && entry.fresh(ttl) {
+		return entry.response(req), nil
+	}
+	if entry != nil && entry.revalidate(req) {
+		successCodes = append(successCodes[:len(successCodes):len(successCodes)], http.StatusNotModified)
+	}
+
+	resp, err = c.do(req, successCodes)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusNotModified && entry != nil {
+		resp.Body.Close()
+		c.Cache.touch(u, entry)
+		return entry.response(req), nil
+	}
+	if resp.StatusCode == http.StatusOK {
+		return c.Cache.store(u, resp)
+	}
+	return resp, nil
+}
+
+// do sends a request, retrying it as long as it fails with a retryable error
+func (c *Client) do(req *http.Request, successCodes []int) (*http.Response, error) {
+	var (
+		err  error
+		resp *http.Response
+		ctx  = req.Context()
+	)
+
+	attempts := c.Retry.MaxAttempts
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	for attempt := 1; attempt <= attempts; attempt++ {
+		release := func() {}
+		if c.Limiter != nil {
+			release, err = c.Limiter.Wait(ctx, req.URL.Host, c.Limit)
+			if err != nil {
+				return nil, &cancelledError{err: err}
+			}
+		}
+
+		resp, err = c.httpClient().Do(req)
+		if err == nil && c.success(successCodes, resp.StatusCode) {
+			resp.Body = &releaseBody{ReadCloser: resp.Body, release: release}
+			return resp, nil
+		}
+		if err == nil {
+			resp.Body.Close()
+			err = &StatusError{Code: resp.StatusCode, URL: req.URL, RetryAfter: c.Retry.retryAfter(resp)}
+		}
+		release()
+
+		if ctxErr := contextError(ctx); ctxErr != nil {
+			return nil, ctxErr
+		}
+
+		if !Retryable(err) || attempt == attempts {
+			break
+		}
+
+		delay := c.Retry.Delay(attempt)
+		if statusError, ok := err.(*StatusError); ok && statusError.RetryAfter > 0 {
+			delay = statusError.RetryAfter
+		}
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return nil, contextError(ctx)
 		}
-		time.Sleep(500 * time.Millisecond)
 	}
 
-	return nil, fmt.Errorf("Success code never reached (%s)", u)
+	return nil, err
 }
 
 // GetBody parse a GET response
@@ -0,0 +1,282 @@
+package client
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CookieJar is an http.CookieJar persisted to a file
+type CookieJar struct {
+	path    string
+	mutex   sync.Mutex
+	jar     *cookiejar.Jar
+	cookies map[string]*cookieEntry
+}
+
+type cookieEntry struct {
+	Name     string    `json:"name"`
+	Value    string    `json:"value"`
+	Domain   string    `json:"domain"`
+	Path     string    `json:"path"`
+	HostOnly bool      `json:"host_only"`
+	Secure   bool      `json:"secure"`
+	HTTPOnly bool      `json:"http_only"`
+	Expires  time.Time `json:"expires"`
+}
+
+func (e *cookieEntry) key() string {
+	return e.Domain + ";" + e.Path + ";" + e.Name
+}
+
+// same tells whether both entries are the same cookie, Max-Age cookies sent
+// again expire a bit later and stay the same
+func (e *cookieEntry) same(other *cookieEntry) bool {
+	a, b := *e, *other
+	a.Expires, b.Expires = time.Time{}, time.Time{}
+	delay := e.Expires.Sub(other.Expires)
+	return a == b && e.Expires.IsZero() == other.Expires.IsZero() && delay < time.Minute && delay > -time.Minute
+}
+
+func (e *cookieEntry) expired() bool {
+	return !e.Expires.IsZero() && e.Expires.Before(time.Now())
+}
+
+// url returns an URL the cookie can be set from
+func (e *cookieEntry) url() *url.URL {
+	scheme := "http"
+	if e.Secure {
+		scheme = "https"
+	}
+	return &url.URL{Scheme: scheme, Host: strings.TrimPrefix(e.Domain, "."), Path: e.Path}
+}
+
+func (e *cookieEntry) cookie() *http.Cookie {
+	cookie := &http.Cookie{
+		Name:     e.Name,
+		Value:    e.Value,
+		Path:     e.Path,
+		Secure:   e.Secure,
+		HttpOnly: e.HTTPOnly,
+		Expires:  e.Expires,
+	}
+	if !e.HostOnly {
+		cookie.Domain = e.Domain
+	}
+	return cookie
+}
+
+var (
+	cookieJarsMutex sync.Mutex
+	cookieJars      = map[string]*CookieJar{}
+)
+
+// OpenCookieJar returns the CookieJar stored at given path, jars are shared
+// within the process so every Client of a backend sees the same session
+func OpenCookieJar(path string) (*CookieJar, error) {
+	cookieJarsMutex.Lock()
+	defer cookieJarsMutex.Unlock()
+
+	if j, ok := cookieJars[path]; ok {
+		return j, nil
+	}
+
+	j, err := loadCookieJar(path)
+	if err != nil {
+		return nil, err
+	}
+
+	cookieJars[path] = j
+	return j, nil
+}
+
+// loadCookieJar reads the CookieJar stored at given path
+func loadCookieJar(path string) (*CookieJar, error) {
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	j := &CookieJar{path: path, jar: jar, cookies: map[string]*cookieEntry{}}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if err == nil {
+		var entries []*cookieEntry
+		err = json.Unmarshal(data, &entries)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cookie jar %s: %s", path, err)
+		}
+		j.add(entries)
+	}
+
+	return j, nil
+}
+
+// SetCookies implements http.CookieJar interface
+func (j *CookieJar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	entries := make([]*cookieEntry, 0, len(cookies))
+	for _, cookie := range cookies {
+		entry := &cookieEntry{
+			Name:     cookie.Name,
+			Value:    cookie.Value,
+			Domain:   cookie.Domain,
+			Path:     cookie.Path,
+			Secure:   cookie.Secure,
+			HTTPOnly: cookie.HttpOnly,
+			Expires:  cookie.Expires,
+		}
+		if len(entry.Domain) == 0 {
+			entry.Domain = u.Hostname()
+			entry.HostOnly = true
+		}
+		if len(entry.Path) == 0 {
+			entry.Path = "/"
+		}
+		if cookie.MaxAge > 0 {
+			entry.Expires = time.Now().Add(time.Duration(cookie.MaxAge) * time.Second)
+		} else if cookie.MaxAge < 0 {
+			entry.Expires = time.Unix(1, 0)
+		}
+		entries = append(entries, entry)
+	}
+
+	// Sites send the same cookies again with most responses, the file is
+	// only written when they change
+	j.jar.SetCookies(u, cookies)
+	if j.add(entries) {
+		j.Save()
+	}
+}
+
+// Cookies implements http.CookieJar interface
+func (j *CookieJar) Cookies(u *url.URL) []*http.Cookie {
+	return j.jar.Cookies(u)
+}
+
+// add stores given cookies and tells whether any changed
+func (j *CookieJar) add(entries []*cookieEntry) bool {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	changed := false
+	for _, entry := range entries {
+		previous, ok := j.cookies[entry.key()]
+		if entry.expired() {
+			delete(j.cookies, entry.key())
+			changed = changed || ok
+			continue
+		}
+		j.cookies[entry.key()] = entry
+		j.jar.SetCookies(entry.url(), []*http.Cookie{entry.cookie()})
+		changed = changed || !ok || !previous.same(entry)
+	}
+	return changed
+}
+
+// Save writes cookies to the jar file
+func (j *CookieJar) Save() error {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	entries := make([]*cookieEntry, 0, len(j.cookies))
+	for key, entry := range j.cookies {
+		if entry.expired() {
+			delete(j.cookies, key)
+			continue
+		}
+		entries = append(entries, entry)
+	}
+
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(j.path), 0700)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(j.path, data, 0600)
+}
+
+// ImportNetscape loads cookies from a Netscape cookies.txt file
+func (j *CookieJar) ImportNetscape(r io.Reader) error {
+	var entries []*cookieEntry
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+
+		httpOnly := strings.HasPrefix(text, "#HttpOnly_")
+		if httpOnly {
+			text = strings.TrimPrefix(text, "#HttpOnly_")
+		}
+		if len(text) == 0 || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		fields := strings.Split(text, "\t")
+		if len(fields) != 7 {
+			return fmt.Errorf("invalid cookies.txt line %d: expected 7 fields, got %d", line, len(fields))
+		}
+
+		expires, err := strconv.ParseInt(fields[4], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid cookies.txt line %d: %s", line, err)
+		}
+
+		entry := &cookieEntry{
+			Domain:   fields[0],
+			HostOnly: !strings.EqualFold(fields[1], "TRUE"),
+			Path:     fields[2],
+			Secure:   strings.EqualFold(fields[3], "TRUE"),
+			Name:     fields[5],
+			Value:    fields[6],
+			HTTPOnly: httpOnly,
+		}
+		if expires > 0 {
+			entry.Expires = time.Unix(expires, 0)
+		}
+		if entry.HostOnly {
+			entry.Domain = strings.TrimPrefix(entry.Domain, ".")
+		}
+		entries = append(entries, entry)
+	}
+
+	err := scanner.Err()
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		return errors.New("no cookie found in cookies.txt")
+	}
+
+	j.add(entries)
+	return j.Save()
+}
+
+// WithCookieJar returns a copy of Client storing cookies in given jar
+func (c *Client) WithCookieJar(jar http.CookieJar) *Client {
+	httpClient := *c.httpClient()
+	httpClient.Jar = jar
+
+	clientCopy := *c
+	clientCopy.HTTPClient = &httpClient
+	return &clientCopy
+}
@@ -0,0 +1,147 @@
+package client
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func tempJarPath(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "katago-cookies-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return filepath.Join(dir, "cookies", "mangafox.json")
+}
+
+func cookieValues(jar http.CookieJar, rawURL string) map[string]string {
+	u, _ := url.Parse(rawURL)
+	values := map[string]string{}
+	for _, cookie := range jar.Cookies(u) {
+		values[cookie.Name] = cookie.Value
+	}
+	return values
+}
+
+func TestCookieJarPersistence(t *testing.T) {
+	path := tempJarPath(t)
+	jar, err := OpenCookieJar(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := OpenCookieJar(path); again != jar {
+		t.Error("jar of the same path not shared")
+	}
+
+	u, _ := url.Parse("https://fanfox.net/manga/")
+	jar.SetCookies(u, []*http.Cookie{
+		{Name: "session", Value: "alice", MaxAge: 3600},
+		{Name: "isAdult", Value: "1", Domain: ".fanfox.net", Path: "/"},
+		{Name: "gone", Value: "x", Expires: time.Now().Add(-time.Hour)},
+	})
+
+	// Another process reads the jar file back
+	reopened, err := loadCookieJar(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := cookieValues(reopened, "https://m.fanfox.net/manga/")
+	if len(got) != 1 || got["isAdult"] != "1" {
+		t.Errorf("got subdomain cookies %v, want isAdult only", got)
+	}
+	got = cookieValues(reopened, "https://fanfox.net/manga/berserk/")
+	if len(got) != 2 || got["session"] != "alice" || got["isAdult"] != "1" {
+		t.Errorf("got cookies %v, want session and isAdult", got)
+	}
+
+	// A deleted cookie is forgotten
+	jar.SetCookies(u, []*http.Cookie{{Name: "session", Value: "", MaxAge: -1}})
+	reopened, _ = loadCookieJar(path)
+	if got := cookieValues(reopened, "https://fanfox.net/manga/"); len(got) != 1 {
+		t.Errorf("got cookies %v after deletion, want isAdult only", got)
+	}
+}
+
+func TestCookieJarSavesChangesOnly(t *testing.T) {
+	path := tempJarPath(t)
+	jar, err := OpenCookieJar(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	u, _ := url.Parse("https://fanfox.net/")
+	jar.SetCookies(u, []*http.Cookie{{Name: "session", Value: "alice", MaxAge: 3600}})
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("new cookie not saved: %s", err)
+	}
+
+	os.Remove(path)
+	jar.SetCookies(u, []*http.Cookie{{Name: "session", Value: "alice", MaxAge: 3600}})
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("jar saved again for an unchanged cookie")
+	}
+
+	jar.SetCookies(u, []*http.Cookie{{Name: "session", Value: "bob", MaxAge: 3600}})
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("changed cookie not saved: %s", err)
+	}
+}
+
+func TestImportNetscape(t *testing.T) {
+	future := strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10)
+	past := strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10)
+	cookiesTxt := strings.Join([]string{
+		"# Netscape HTTP Cookie File",
+		"",
+		".fanfox.net\tTRUE\t/\tFALSE\t" + future + "\tisAdult\t1",
+		"#HttpOnly_fanfox.net\tFALSE\t/\tTRUE\t0\tsession\talice",
+		"fanfox.net\tFALSE\t/manga\tFALSE\t" + past + "\texpired\tx",
+	}, "\n")
+
+	path := tempJarPath(t)
+	jar, err := OpenCookieJar(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = jar.ImportNetscape(strings.NewReader(cookiesTxt))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entries := map[string]*cookieEntry{}
+	for _, entry := range jar.cookies {
+		entries[entry.Name] = entry
+	}
+	if len(entries) != 2 {
+		t.Fatalf("got cookies %v, want isAdult and session", entries)
+	}
+	if adult := entries["isAdult"]; adult.HostOnly || adult.HTTPOnly || adult.Expires.IsZero() {
+		t.Errorf("got isAdult %+v, want a domain cookie expiring", adult)
+	}
+	if session := entries["session"]; !session.HostOnly || !session.HTTPOnly || !session.Secure || !session.Expires.IsZero() {
+		t.Errorf("got session %+v, want a secure host only session cookie", session)
+	}
+
+	if got := cookieValues(jar, "https://fanfox.net/manga/"); len(got) != 2 {
+		t.Errorf("got cookies %v, want isAdult and session", got)
+	}
+	if got := cookieValues(jar, "http://m.fanfox.net/"); len(got) != 1 || got["isAdult"] != "1" {
+		t.Errorf("got subdomain cookies %v, want isAdult only", got)
+	}
+	if reopened, _ := loadCookieJar(path); len(reopened.cookies) != 2 {
+		t.Errorf("imported cookies not saved, got %d", len(reopened.cookies))
+	}
+
+	for _, invalid := range []string{"fanfox.net\tFALSE\t/\tFALSE\tsoon\tname\tvalue", "fanfox.net\tFALSE\t/", "# only comments"} {
+		if err := jar.ImportNetscape(strings.NewReader(invalid)); err == nil {
+			t.Errorf("%q imported", invalid)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+var (
+	// ErrNotFound is matched by errors of resources which do not exist
+	ErrNotFound = errors.New("not found")
+	// ErrRateLimited is matched by errors of throttled requests
+	ErrRateLimited = errors.New("rate limited")
+	// ErrCancelled is matched by errors of cancelled requests
+	ErrCancelled = errors.New("cancelled")
+)
+
+// StatusError is returned when a response status code is not a success code
+type StatusError struct {
+	Code int
+	URL  *url.URL
+	// RetryAfter is the delay asked by the server, if any
+	RetryAfter time.Duration
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("unexpected status code %d %s (%s)", e.Code, http.StatusText(e.Code), e.URL)
+}
+
+// Is implements errors.Is interface
+func (e *StatusError) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return e.Code == http.StatusNotFound || e.Code == http.StatusGone
+	case ErrRateLimited:
+		return e.Code == http.StatusTooManyRequests
+	default:
+		return false
+	}
+}
+
+// Temporary tells if the request may succeed later
+func (e *StatusError) Temporary() bool {
+	return e.Code == http.StatusTooManyRequests || (e.Code >= 500 && e.Code != http.StatusNotImplemented)
+}
+
+// cancelledError wraps a context error so it matches ErrCancelled
+type cancelledError struct {
+	err error
+}
+
+func (e *cancelledError) Error() string {
+	return fmt.Sprintf("request cancelled: %s", e.err)
+}
+
+func (e *cancelledError) Unwrap() error {
+	return e.err
+}
+
+func (e *cancelledError) Is(target error) bool {
+	return target == ErrCancelled
+}
+
+// Retryable tells if a failed request is worth retrying, transport errors
+// are only when they are timeouts or refused or reset connections, unknown
+// hosts, certificate errors or unsupported schemes would fail again
+func Retryable(err error) bool {
+	if err == nil || errors.Is(err, ErrCancelled) {
+		return false
+	}
+
+	var statusError *StatusError
+	if errors.As(err, &statusError) {
+		return statusError.Temporary()
+	}
+
+	var netError net.Error
+	if errors.As(err, &netError) && netError.Timeout() {
+		return true
+	}
+	return errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET)
+}
+
+func contextError(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return &cancelledError{err: err}
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package client
+
+import (
+	"crypto/x509"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// timeoutError is a net.Error timing out
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+// requestError wraps given error as http.Client does
+func requestError(err error) error {
+	return &url.Error{Op: "Get", URL: "https://example.com/", Err: err}
+}
+
+func dialError(errno syscall.Errno) error {
+	return requestError(&net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", errno)})
+}
+
+func TestRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"timeout", requestError(timeoutError{}), true},
+		{"connection refused", dialError(syscall.ECONNREFUSED), true},
+		{"connection reset", requestError(&net.OpError{Op: "read", Net: "tcp", Err: os.NewSyscallError("read", syscall.ECONNRESET)}), true},
+		{"server error", &StatusError{Code: http.StatusBadGateway}, true},
+		{"rate limited", &StatusError{Code: http.StatusTooManyRequests}, true},
+		{"not found", &StatusError{Code: http.StatusNotFound}, false},
+		{"no such host", requestError(&net.OpError{Op: "dial", Net: "tcp", Err: &net.DNSError{Err: "no such host", Name: "example.com", IsNotFound: true}}), false},
+		{"unknown authority", requestError(x509.UnknownAuthorityError{}), false},
+		{"invalid certificate", requestError(x509.CertificateInvalidError{Reason: x509.Expired}), false},
+		{"unsupported scheme", requestError(errors.New(`unsupported protocol scheme "ftp"`)), false},
+		{"cancelled", &cancelledError{err: errors.New("context canceled")}, false},
+		{"nil", nil, false},
+	}
+
+	for _, test := range tests {
+		if got := Retryable(test.err); got != test.want {
+			t.Errorf("%s: Retryable(%v) = %t, want %t", test.name, test.err, got, test.want)
+		}
+	}
+}
+
+func TestRetryableRequestErrors(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closed := "http://" + listener.Addr().String()
+	listener.Close()
+
+	tests := []struct {
+		url  string
+		want bool
+	}{
+		{closed, true},
+		{"ftp://example.com/file", false},
+	}
+
+	c := NewClient()
+	c.Limiter = nil
+	c.Retry = RetryPolicy{MaxAttempts: 1}
+	for _, test := range tests {
+		u, _ := url.Parse(test.url)
+		_, err := c.Get(u, []int{http.StatusOK})
+		if err == nil {
+			t.Errorf("%s: request succeeded", test.url)
+			continue
+		}
+		if got := Retryable(err); got != test.want {
+			t.Errorf("%s: Retryable(%v) = %t, want %t", test.url, err, got, test.want)
+		}
+	}
+}
+
+func TestRetryAfterIsCapped(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			w.Header().Set("Retry-After", "86400")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	c := NewClient()
+	c.Limiter = nil
+	c.Retry = RetryPolicy{MaxAttempts: 2, MaxDelay: 50 * time.Millisecond}
+
+	u, _ := url.Parse(server.URL)
+	done := make(chan error)
+	go func() {
+		resp, err := c.Get(u, []int{http.StatusOK})
+		if err == nil {
+			resp.Body.Close()
+		}
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Retry-After was not capped by MaxDelay")
+	}
+}
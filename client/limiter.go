@@ -0,0 +1,198 @@
+package client
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+)
+
+// Limit represents politeness rules applied to a host
+type Limit struct {
+	// Rate is the number of requests per second, zero means unlimited
+	Rate float64
+	// Burst is the number of requests allowed to be sent at once
+	Burst int
+	// MaxConnections is the number of concurrent connections, zero means unlimited
+	MaxConnections int
+}
+
+// Limiter throttles requests per host, a host has a single limiter shared
+// by every client, applying the strictest limit any of them has
+type Limiter struct {
+	mutex sync.Mutex
+	hosts map[string]*hostLimiter
+}
+
+// DefaultLimiter is the Limiter shared by every Client of the process
+var DefaultLimiter = NewLimiter()
+
+// NewLimiter returns a new Limiter
+func NewLimiter() *Limiter {
+	return &Limiter{hosts: map[string]*hostLimiter{}}
+}
+
+// strictest returns a Limit as strict as both given ones, zero values are no
+// limit
+func strictest(a Limit, b Limit) Limit {
+	minimum := func(x float64, y float64) float64 {
+		if x <= 0 || (y > 0 && y < x) {
+			return y
+		}
+		return x
+	}
+
+	burst := minimum(float64(a.Burst), float64(b.Burst))
+	switch {
+	case a.Rate <= 0:
+		burst = float64(b.Burst)
+	case b.Rate <= 0:
+		burst = float64(a.Burst)
+	}
+
+	return Limit{
+		Rate:           minimum(a.Rate, b.Rate),
+		Burst:          int(burst),
+		MaxConnections: int(minimum(float64(a.MaxConnections), float64(b.MaxConnections))),
+	}
+}
+
+type hostLimiter struct {
+	mutex  sync.Mutex
+	limit  Limit
+	tokens float64
+	last   time.Time
+	// active is the number of connections in use, released is closed and
+	// replaced whenever one is released
+	active   int
+	released chan struct{}
+}
+
+func newHostLimiter(limit Limit) *hostLimiter {
+	h := &hostLimiter{
+		limit:    limit,
+		last:     time.Now(),
+		released: make(chan struct{}),
+	}
+	h.tokens = h.burst()
+	return h
+}
+
+func (h *hostLimiter) burst() float64 {
+	if h.limit.Burst < 1 {
+		return 1
+	}
+	return float64(h.limit.Burst)
+}
+
+// restrict applies given limit on top of the current one
+func (h *hostLimiter) restrict(limit Limit) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	h.limit = strictest(h.limit, limit)
+	if h.tokens > h.burst() {
+		h.tokens = h.burst()
+	}
+}
+
+// reserve takes a token and returns how long to wait before using it
+func (h *hostLimiter) reserve() time.Duration {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if h.limit.Rate <= 0 {
+		return 0
+	}
+
+	now := time.Now()
+	h.tokens += now.Sub(h.last).Seconds() * h.limit.Rate
+	if h.tokens > h.burst() {
+		h.tokens = h.burst()
+	}
+	h.last = now
+
+	h.tokens--
+	if h.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-h.tokens / h.limit.Rate * float64(time.Second))
+}
+
+// acquire waits for a connection to be available and takes it
+func (h *hostLimiter) acquire(ctx context.Context) error {
+	for {
+		h.mutex.Lock()
+		if h.limit.MaxConnections <= 0 || h.active < h.limit.MaxConnections {
+			h.active++
+			h.mutex.Unlock()
+			return nil
+		}
+		released := h.released
+		h.mutex.Unlock()
+
+		select {
+		case <-released:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (h *hostLimiter) release() {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	h.active--
+	close(h.released)
+	h.released = make(chan struct{})
+}
+
+func (l *Limiter) host(host string, limit Limit) *hostLimiter {
+	l.mutex.Lock()
+	h, ok := l.hosts[host]
+	if !ok {
+		h = newHostLimiter(limit)
+		l.hosts[host] = h
+	}
+	l.mutex.Unlock()
+
+	if ok {
+		h.restrict(limit)
+	}
+	return h
+}
+
+// Wait blocks until a request to given host is allowed and returns a
+// function releasing the acquired connection
+func (l *Limiter) Wait(ctx context.Context, host string, limit Limit) (func(), error) {
+	h := l.host(host, limit)
+
+	select {
+	case <-time.After(h.reserve()):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	err := h.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var once sync.Once
+	return func() {
+		once.Do(h.release)
+	}, nil
+}
+
+// releaseBody releases a host connection once the response body is closed
+type releaseBody struct {
+	io.ReadCloser
+	release func()
+}
+
+func (b *releaseBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.release()
+	return err
+}
@@ -0,0 +1,113 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestLimiterSharesStrictestLimitOfHost(t *testing.T) {
+	l := NewLimiter()
+	capped := Limit{MaxConnections: 1}
+	other := Limit{Rate: 100, Burst: 10}
+
+	release, err := l.Wait(context.Background(), "example.com", capped)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Another client of the same host is held by the connection cap too
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = l.Wait(ctx, "example.com", other)
+	if err != context.DeadlineExceeded {
+		t.Fatalf("connection cap not shared, got %v", err)
+	}
+
+	release()
+	otherRelease, err := l.Wait(context.Background(), "example.com", other)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherRelease()
+
+	// Other hosts are not limited
+	otherHostRelease, err := l.Wait(context.Background(), "example.org", other)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherHostRelease()
+}
+
+func TestStrictest(t *testing.T) {
+	tests := []struct {
+		a    Limit
+		b    Limit
+		want Limit
+	}{
+		{Limit{}, Limit{}, Limit{}},
+		{Limit{Rate: 2, Burst: 5, MaxConnections: 4}, Limit{}, Limit{Rate: 2, Burst: 5, MaxConnections: 4}},
+		{Limit{}, Limit{Rate: 2, Burst: 5, MaxConnections: 4}, Limit{Rate: 2, Burst: 5, MaxConnections: 4}},
+		{Limit{Rate: 2, Burst: 5, MaxConnections: 4}, Limit{Rate: 4, Burst: 1, MaxConnections: 8}, Limit{Rate: 2, Burst: 1, MaxConnections: 4}},
+		{Limit{Rate: 4, Burst: 4}, Limit{MaxConnections: 1}, Limit{Rate: 4, Burst: 4, MaxConnections: 1}},
+	}
+
+	for _, test := range tests {
+		if got := strictest(test.a, test.b); got != test.want {
+			t.Errorf("strictest(%+v, %+v) = %+v, want %+v", test.a, test.b, got, test.want)
+		}
+	}
+}
+
+func TestClientsShareHostLimit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	limiter := NewLimiter()
+	slow := NewClient().WithLimit(Limit{Rate: 20, Burst: 1})
+	fast := NewClient().WithLimit(Limit{Rate: 1000, Burst: 100})
+	for _, c := range []*Client{slow, fast} {
+		c.Limiter = limiter
+		c.Retry = RetryPolicy{MaxAttempts: 1}
+	}
+
+	u, _ := url.Parse(server.URL)
+	start := time.Now()
+	for _, c := range []*Client{slow, fast, fast, slow, fast} {
+		resp, err := c.Get(u, []int{http.StatusOK})
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+
+	// The host gets 20 requests per second whichever client sends them,
+	// the first one uses the burst and the four others wait 50ms each
+	if elapsed := time.Since(start); elapsed < 190*time.Millisecond {
+		t.Fatalf("clients did not share the host limit, took %s", elapsed)
+	}
+}
+
+func TestLimiterRate(t *testing.T) {
+	l := NewLimiter()
+	limit := Limit{Rate: 20, Burst: 1}
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		release, err := l.Wait(context.Background(), "example.com", limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		release()
+	}
+
+	// First request uses the burst, the two others wait 50ms each
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Fatalf("requests were not throttled, took %s", elapsed)
+	}
+}
@@ -0,0 +1,273 @@
+package client
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nwaples/rardecode"
+)
+
+// ArchiveExtensions are the extensions of archives LocalTransport reads into
+var ArchiveExtensions = map[string]bool{
+	".cbz": true,
+	".zip": true,
+	".cbr": true,
+	".rar": true,
+}
+
+// IsArchive reports whether given file name is an archive
+func IsArchive(name string) bool {
+	return ArchiveExtensions[strings.ToLower(filepath.Ext(name))]
+}
+
+// LocalTransport is a RoundTripper serving file URLs, a path going through
+// an archive like file:///mangas/foo/c001.cbz/01.jpg serves the archive entry
+type LocalTransport struct{}
+
+// WithLocalFiles returns a copy of Client reading file URLs with
+// LocalTransport, and nothing else
+func (c *Client) WithLocalFiles() *Client {
+	clientCopy := *c
+	clientCopy.HTTPClient = &http.Client{Transport: LocalTransport{}}
+	return &clientCopy
+}
+
+// RoundTrip implements http.RoundTripper interface
+func (LocalTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Scheme != "file" {
+		return nil, fmt.Errorf("%s is not a file URL", req.URL)
+	}
+	if req.Method != http.MethodGet {
+		return localResponse(req, http.StatusMethodNotAllowed, nil), nil
+	}
+
+	name := filepath.FromSlash(req.URL.Path)
+
+	var (
+		data []byte
+		err  error
+	)
+	if archive, entry, ok := SplitArchivePath(name); ok {
+		data, err = ReadArchiveEntry(archive, entry)
+	} else {
+		data, err = ioutil.ReadFile(name)
+	}
+	if os.IsNotExist(err) {
+		return localResponse(req, http.StatusNotFound, nil), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return localResponse(req, http.StatusOK, data), nil
+}
+
+func localResponse(req *http.Request, code int, data []byte) *http.Response {
+	header := http.Header{}
+	if code == http.StatusOK {
+		header.Set("Content-Type", mime.TypeByExtension(path.Ext(req.URL.Path)))
+		header.Set("Content-Length", strconv.Itoa(len(data)))
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", code, http.StatusText(code)),
+		StatusCode:    code,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(bytes.NewReader(data)),
+		ContentLength: int64(len(data)),
+		Request:       req,
+	}
+}
+
+// SplitArchivePath splits a path going through an archive into the archive
+// path and the entry name
+func SplitArchivePath(name string) (string, string, bool) {
+	for archive := name; archive != filepath.Dir(archive); archive = filepath.Dir(archive) {
+		if !IsArchive(archive) {
+			continue
+		}
+
+		info, err := os.Stat(archive)
+		if err != nil || info.IsDir() {
+			continue
+		}
+
+		entry := strings.TrimPrefix(filepath.ToSlash(strings.TrimPrefix(name, archive)), "/")
+		return archive, entry, len(entry) > 0
+	}
+	return "", "", false
+}
+
+// ArchiveEntries returns the names of given archive files
+func ArchiveEntries(archive string) ([]string, error) {
+	names := make([]string, 0)
+	err := walkArchive(archive, func(name string, r io.Reader) (bool, error) {
+		names = append(names, name)
+		return false, nil
+	})
+	return names, err
+}
+
+// ReadArchiveEntry returns the content of given archive file, archives are
+// read whole on first access and their files kept for the next ones, as
+// reading a single file of a solid RAR archive decompresses those before it
+func ReadArchiveEntry(archive string, entry string) ([]byte, error) {
+	files, err := readArchive(archive)
+	if err != nil {
+		return nil, err
+	}
+
+	data, ok := files[entry]
+	if !ok {
+		return nil, &os.PathError{Op: "open", Path: path.Join(archive, entry), Err: os.ErrNotExist}
+	}
+	return data, nil
+}
+
+// archiveCacheSize is the number of archives whose files are kept in memory
+// by ReadArchiveEntry
+const archiveCacheSize = 4
+
+// cachedArchive holds the files of an archive, read once ready is closed
+type cachedArchive struct {
+	path    string
+	modTime time.Time
+	size    int64
+
+	ready chan struct{}
+	files map[string][]byte
+	err   error
+}
+
+// archiveCache holds the most recently read archives last
+var archiveCache struct {
+	mutex    sync.Mutex
+	archives []*cachedArchive
+}
+
+// readArchive returns the files of given archive, reading it unless it is
+// cached and unchanged since, concurrent readers of an archive wait for a
+// single read
+func readArchive(archive string) (map[string][]byte, error) {
+	info, err := os.Stat(archive)
+	if err != nil {
+		return nil, err
+	}
+
+	archiveCache.mutex.Lock()
+	var cached *cachedArchive
+	for index, candidate := range archiveCache.archives {
+		if candidate.path != archive {
+			continue
+		}
+		archiveCache.archives = append(archiveCache.archives[:index:index], archiveCache.archives[index+1:]...)
+		if candidate.modTime.Equal(info.ModTime()) && candidate.size == info.Size() {
+			cached = candidate
+		}
+		break
+	}
+	loaded := cached != nil
+	if !loaded {
+		cached = &cachedArchive{path: archive, modTime: info.ModTime(), size: info.Size(), ready: make(chan struct{})}
+	}
+	archiveCache.archives = append(archiveCache.archives, cached)
+	if len(archiveCache.archives) > archiveCacheSize {
+		archiveCache.archives = archiveCache.archives[len(archiveCache.archives)-archiveCacheSize:]
+	}
+	archiveCache.mutex.Unlock()
+
+	if loaded {
+		<-cached.ready
+		return cached.files, cached.err
+	}
+
+	files := map[string][]byte{}
+	err = walkArchive(archive, func(name string, r io.Reader) (bool, error) {
+		data, err := ioutil.ReadAll(r)
+		files[name] = data
+		return false, err
+	})
+	if err != nil {
+		archiveCache.mutex.Lock()
+		for index, candidate := range archiveCache.archives {
+			if candidate == cached {
+				archiveCache.archives = append(archiveCache.archives[:index:index], archiveCache.archives[index+1:]...)
+				break
+			}
+		}
+		archiveCache.mutex.Unlock()
+		files = nil
+	}
+
+	cached.files, cached.err = files, err
+	close(cached.ready)
+	return files, err
+}
+
+// walkArchive calls visit for every file of given archive until it returns
+// true, ZIP archives are tried first as CBR files often are ZIP archives
+func walkArchive(archive string, visit func(name string, r io.Reader) (bool, error)) error {
+	zipReader, err := zip.OpenReader(archive)
+	if err == nil {
+		defer zipReader.Close()
+
+		for _, file := range zipReader.File {
+			if file.FileInfo().IsDir() {
+				continue
+			}
+
+			r, err := file.Open()
+			if err != nil {
+				return err
+			}
+			done, err := visit(file.Name, r)
+			r.Close()
+			if err != nil || done {
+				return err
+			}
+		}
+		return nil
+	}
+	if err != zip.ErrFormat {
+		return err
+	}
+
+	rarReader, err := rardecode.OpenReader(archive, "")
+	if err != nil {
+		return err
+	}
+	defer rarReader.Close()
+
+	for {
+		header, err := rarReader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if header.IsDir {
+			continue
+		}
+
+		done, err := visit(filepath.ToSlash(header.Name), rarReader)
+		if err != nil || done {
+			return err
+		}
+	}
+}
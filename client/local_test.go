@@ -0,0 +1,151 @@
+package client
+
+import (
+	"archive/zip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// chapterFiles are the files of testdata/chapter.cbr, written to ZIP
+// archives by writeZip
+var chapterFiles = map[string]string{
+	"01.png":       "page one",
+	"02.png":       "page two",
+	"extra/03.png": "page three",
+}
+
+func writeZip(t *testing.T, name string, files map[string]string) {
+	file, err := os.Create(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	w := zip.NewWriter(file)
+	for entry, content := range files {
+		f, err := w.Create(entry)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = f.Write([]byte(content))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = w.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func tempArchiveDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "katago-archives-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return dir
+}
+
+func TestSplitArchivePath(t *testing.T) {
+	dir := tempArchiveDir(t)
+	writeZip(t, filepath.Join(dir, "c001.cbz"), chapterFiles)
+	err := os.MkdirAll(filepath.Join(dir, "c002.cbz"), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		archive string
+		entry   string
+		ok      bool
+	}{
+		{"c001.cbz/01.png", "c001.cbz", "01.png", true},
+		{"c001.cbz/extra/03.png", "c001.cbz", "extra/03.png", true},
+		{"c001.cbz", "c001.cbz", "", false},
+		{"c002.cbz/01.png", "", "", false},
+		{"c003.cbz/01.png", "", "", false},
+		{"c001/01.png", "", "", false},
+	}
+
+	for _, test := range tests {
+		archive, entry, ok := SplitArchivePath(filepath.Join(dir, filepath.FromSlash(test.name)))
+		if len(test.archive) > 0 {
+			test.archive = filepath.Join(dir, test.archive)
+		}
+		if archive != test.archive || entry != test.entry || ok != test.ok {
+			t.Errorf("SplitArchivePath(%q) = %q, %q, %v, want %q, %q, %v", test.name, archive, entry, ok, test.archive, test.entry, test.ok)
+		}
+	}
+}
+
+func TestReadArchiveEntry(t *testing.T) {
+	dir := tempArchiveDir(t)
+	// c001-zip.cbr is a ZIP archive, as CBR files often are
+	archives := []string{"testdata/chapter.cbr"}
+	for _, name := range []string{"c001.cbz", "c001.zip", "c001-zip.cbr"} {
+		archive := filepath.Join(dir, name)
+		writeZip(t, archive, chapterFiles)
+		archives = append(archives, archive)
+	}
+
+	for _, archive := range archives {
+		name := filepath.Base(archive)
+		for entry, content := range chapterFiles {
+			data, err := ReadArchiveEntry(archive, entry)
+			if err != nil {
+				t.Errorf("%s: %s: %s", name, entry, err)
+				continue
+			}
+			if string(data) != content {
+				t.Errorf("%s: %s: got %q, want %q", name, entry, data, content)
+			}
+		}
+
+		_, err := ReadArchiveEntry(archive, "04.png")
+		if !os.IsNotExist(err) {
+			t.Errorf("%s: got %v for a missing entry, want a not exist error", name, err)
+		}
+	}
+}
+
+func TestReadArchiveEntryReadsArchiveOnce(t *testing.T) {
+	archive := filepath.Join(tempArchiveDir(t), "c001.cbz")
+	writeZip(t, archive, chapterFiles)
+	info, err := os.Stat(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = ReadArchiveEntry(archive, "01.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// An archive unchanged in size and time is not read again
+	err = ioutil.WriteFile(archive, make([]byte, info.Size()), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.Chtimes(archive, info.ModTime(), info.ModTime())
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := ReadArchiveEntry(archive, "02.png")
+	if err != nil || string(data) != chapterFiles["02.png"] {
+		t.Errorf("got %q, %v, want the cached entry", data, err)
+	}
+
+	err = os.Chtimes(archive, info.ModTime(), info.ModTime().Add(time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = ReadArchiveEntry(archive, "02.png")
+	if err == nil {
+		t.Error("changed archive was not read again")
+	}
+}
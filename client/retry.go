@@ -0,0 +1,82 @@
+package client
+
+import (
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy represents how failed requests are retried
+type RetryPolicy struct {
+	// MaxAttempts is the number of attempts before giving up
+	MaxAttempts int
+	// BaseDelay is the delay before the first retry
+	BaseDelay time.Duration
+	// MaxDelay caps the delay between two attempts
+	MaxDelay time.Duration
+	// Multiplier is applied to the delay after each attempt
+	Multiplier float64
+	// Jitter is the fraction of the delay randomized, between 0 and 1
+	Jitter float64
+}
+
+// DefaultRetryPolicy is the RetryPolicy used by NewClient
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 5,
+	BaseDelay:   500 * time.Millisecond,
+	MaxDelay:    30 * time.Second,
+	Multiplier:  2,
+	Jitter:      0.2,
+}
+
+// Delay returns how long to wait before given retry, starting at 1
+func (p RetryPolicy) Delay(retry int) time.Duration {
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+
+	delay := float64(p.BaseDelay) * math.Pow(multiplier, float64(retry-1))
+	if p.MaxDelay > 0 && delay > float64(p.MaxDelay) {
+		delay = float64(p.MaxDelay)
+	}
+
+	if p.Jitter > 0 {
+		delay += delay * p.Jitter * (2*rand.Float64() - 1)
+	}
+
+	return time.Duration(delay)
+}
+
+// retryAfter returns delay asked by a 429 or 503 response, at most
+// given policy MaxDelay
+func (p RetryPolicy) retryAfter(resp *http.Response) time.Duration {
+	delay := retryAfter(resp)
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		return p.MaxDelay
+	}
+	return delay
+}
+
+func retryAfter(resp *http.Response) time.Duration {
+	if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusServiceUnavailable {
+		return 0
+	}
+
+	value := resp.Header.Get("Retry-After")
+	if len(value) == 0 {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second
+	}
+
+	if date, err := http.ParseTime(value); err == nil && time.Until(date) > 0 {
+		return time.Until(date)
+	}
+
+	return 0
+}
@@ -0,0 +1,155 @@
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// TransportOptions represents how Client connects to sites
+type TransportOptions struct {
+	// Proxy is an http, https or socks5 URL, "direct" disables proxies and
+	// an empty value uses HTTP_PROXY and HTTPS_PROXY environment variables
+	Proxy string
+	// ConnectTimeout is the maximum time to establish a connection
+	ConnectTimeout time.Duration
+	// ReadTimeout is the maximum time to wait for response headers, and for
+	// each read of the response body so stalled downloads fail
+	ReadTimeout time.Duration
+	// MaxIdleConns is the size of the keep-alive connections pool
+	MaxIdleConns int
+	// MaxIdleConnsPerHost is the size of the keep-alive connections pool per host
+	MaxIdleConnsPerHost int
+	// IdleConnTimeout is how long an idle connection is kept alive
+	IdleConnTimeout time.Duration
+	// CAFile is a PEM bundle of certificate authorities trusted besides system ones
+	CAFile string
+}
+
+// DefaultTransportOptions is the TransportOptions used by NewClient
+var DefaultTransportOptions = TransportOptions{
+	ConnectTimeout:      30 * time.Second,
+	ReadTimeout:         60 * time.Second,
+	MaxIdleConns:        100,
+	MaxIdleConnsPerHost: 10,
+	IdleConnTimeout:     90 * time.Second,
+}
+
+// NewHTTPClient returns an http.Client built from given options
+func NewHTTPClient(options TransportOptions) (*http.Client, error) {
+	proxy, err := proxyFunc(options.Proxy)
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConfig, err := tlsConfig(options.CAFile)
+	if err != nil {
+		return nil, err
+	}
+
+	dialer := &net.Dialer{
+		Timeout:   options.ConnectTimeout,
+		KeepAlive: 30 * time.Second,
+	}
+
+	dial := dialer.DialContext
+	if options.ReadTimeout > 0 {
+		dial = func(ctx context.Context, network string, address string) (net.Conn, error) {
+			conn, err := dialer.DialContext(ctx, network, address)
+			if err != nil {
+				return nil, err
+			}
+			return &deadlineConn{Conn: conn, timeout: options.ReadTimeout}, nil
+		}
+	}
+
+	transport := &http.Transport{
+		Proxy:                 proxy,
+		DialContext:           dial,
+		TLSClientConfig:       tlsConfig,
+		TLSHandshakeTimeout:   options.ConnectTimeout,
+		ResponseHeaderTimeout: options.ReadTimeout,
+		MaxIdleConns:          options.MaxIdleConns,
+		MaxIdleConnsPerHost:   options.MaxIdleConnsPerHost,
+		IdleConnTimeout:       options.IdleConnTimeout,
+		ExpectContinueTimeout: time.Second,
+	}
+
+	return &http.Client{Transport: transport, CheckRedirect: checkRedirect}, nil
+}
+
+// checkRedirect follows up to 10 redirects like http.Client does, to http
+// and https URLs only so a site cannot send us to another scheme
+func checkRedirect(req *http.Request, via []*http.Request) error {
+	if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+		return fmt.Errorf("redirect to %s refused, only http and https URLs are followed", req.URL)
+	}
+	if len(via) >= 10 {
+		return errors.New("stopped after 10 redirects")
+	}
+	return nil
+}
+
+// deadlineConn fails reads waiting longer than timeout for data, response
+// header timeout alone does not cover bodies
+type deadlineConn struct {
+	net.Conn
+	timeout time.Duration
+}
+
+func (c *deadlineConn) Read(b []byte) (int, error) {
+	err := c.Conn.SetReadDeadline(time.Now().Add(c.timeout))
+	if err != nil {
+		return 0, err
+	}
+	return c.Conn.Read(b)
+}
+
+func proxyFunc(proxy string) (func(*http.Request) (*url.URL, error), error) {
+	switch proxy {
+	case "":
+		return http.ProxyFromEnvironment, nil
+	case "direct":
+		return nil, nil
+	}
+
+	proxyURL, err := url.Parse(proxy)
+	if err != nil {
+		return nil, err
+	}
+
+	switch proxyURL.Scheme {
+	case "http", "https", "socks5":
+		return http.ProxyURL(proxyURL), nil
+	default:
+		return nil, fmt.Errorf("invalid proxy scheme '%s' (must be http, https or socks5)", proxyURL.Scheme)
+	}
+}
+
+func tlsConfig(caFile string) (*tls.Config, error) {
+	if len(caFile) == 0 {
+		return nil, nil
+	}
+
+	data, err := ioutil.ReadFile(caFile)
+	if err != nil {
+		return nil, err
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, errors.New("no certificate found in CA bundle " + caFile)
+	}
+
+	return &tls.Config{RootCAs: pool}, nil
+}
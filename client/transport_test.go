@@ -0,0 +1,106 @@
+package client
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestReadTimeoutCoversBody(t *testing.T) {
+	stall := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("first bytes"))
+		w.(http.Flusher).Flush()
+		<-stall
+	}))
+	defer server.Close()
+	defer close(stall)
+
+	options := DefaultTransportOptions
+	options.ReadTimeout = 100 * time.Millisecond
+	c, err := NewClient().WithTransport(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Retry = RetryPolicy{MaxAttempts: 1}
+
+	u, _ := url.Parse(server.URL)
+	resp, err := c.Get(u, []int{http.StatusOK})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	done := make(chan error)
+	go func() {
+		_, err := ioutil.ReadAll(resp.Body)
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Fatal("stalled body read did not fail")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("stalled body read hangs")
+	}
+}
+
+func TestRedirectsStayOnHTTP(t *testing.T) {
+	secret, err := ioutil.TempFile("", "katago-secret-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(secret.Name())
+	secret.WriteString("secret")
+	secret.Close()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/file":
+			http.Redirect(w, r, "file://"+filepath.ToSlash(secret.Name()), http.StatusFound)
+		case "/http":
+			http.Redirect(w, r, "/image", http.StatusFound)
+		default:
+			w.Write([]byte("image"))
+		}
+	}))
+	defer server.Close()
+
+	c := NewClient()
+	c.Limiter = nil
+	c.Retry = RetryPolicy{MaxAttempts: 1}
+
+	u, _ := url.Parse(server.URL + "/http")
+	resp, err := c.Get(u, []int{http.StatusOK})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	u, _ = url.Parse(server.URL + "/file")
+	resp, err = c.Get(u, []int{http.StatusOK})
+	if err == nil {
+		data, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		t.Fatalf("redirect to a file URL was followed, read %q", data)
+	}
+
+	// Only the local backend client reads files, and nothing else
+	u, _ = url.Parse("file://" + filepath.ToSlash(secret.Name()))
+	_, err = c.Get(u, []int{http.StatusOK})
+	if err == nil {
+		t.Error("file URL read by default client")
+	}
+	u, _ = url.Parse(server.URL + "/image")
+	_, err = c.WithLocalFiles().Get(u, []int{http.StatusOK})
+	if err == nil {
+		t.Error("http URL fetched by local files client")
+	}
+}
@@ -1,34 +1,58 @@
 package actions
 
 import (
-	"context"
 	"errors"
 	"fmt"
-)
+	"io"
+	"net"
+	"net/http"
 
-// Key is a context key
-type Key string
+	"github.com/toxinu/katago/backends"
+	"github.com/toxinu/katago/client"
+	"github.com/toxinu/katago/cmd/cli/session"
+)
 
-// FromContext returns key's value from given Context
-func FromContext(ctx context.Context, key string) interface{} {
-	return ctx.Value(Key(key))
+// PrintError prints error details to given writer
+func PrintError(out io.Writer, err error) {
+	fmt.Fprintln(out, "Error:", err)
+	if hint := errorHint(err); len(hint) > 0 {
+		fmt.Fprintln(out, " => Tips:", hint)
+	}
 }
 
-// ToContext sets given value to Context's key
-func ToContext(ctx context.Context, key string, value interface{}) context.Context {
-	return context.WithValue(ctx, Key(key), value)
-}
+func errorHint(err error) string {
+	var (
+		statusError *client.StatusError
+		parseError  *backends.ParseError
+		netError    net.Error
+	)
 
-// PrintError print error details
-func PrintError(err error) {
-	fmt.Println("Error:", err)
+	switch {
+	case errors.Is(err, client.ErrCancelled):
+		return "the operation has been cancelled"
+	case errors.Is(err, client.ErrRateLimited):
+		return "the site is throttling us, wait a bit or lower `rate_limit` in your configuration"
+	case errors.Is(err, client.ErrNotFound):
+		return "this content is gone, it may have been removed or licensed"
+	case errors.As(err, &statusError) && statusError.Temporary():
+		return "the site is having trouble, try again later"
+	case errors.As(err, &statusError) && statusError.Code == http.StatusForbidden:
+		return "access is denied, the site may be blocking us"
+	case errors.As(err, &parseError):
+		return fmt.Sprintf("the site layout probably changed, %s backend needs an update", parseError.Backend)
+	case errors.As(err, &netError) && netError.Timeout():
+		return "the site is not responding, check your connection"
+	default:
+		return ""
+	}
 }
 
 // Action represents a cli action
 type Action interface {
-	Run(context.Context, []string) context.Context
-	Help()
-	Tips()
+	Run(*session.Session, []string)
+	Usage() *Usage
+	Help(io.Writer)
+	Tips(io.Writer)
 }
 
 // Actions represents available cli actions
@@ -39,17 +63,20 @@ var Actions = map[string]Action{
 	"manga":    &Manga{},
 	"download": &Download{},
 	"chapters": &Chapters{},
+	"open":     &Open{},
+	"latest":   &Latest{},
+	"help":     &Help{},
+	"session":  &Session{},
 }
 
 // Run execute cli action
-func Run(ctx context.Context, action string, parameters []string) context.Context {
+func Run(s *session.Session, action string, parameters []string) {
 	a, ok := Actions[action]
 	if !ok {
-		PrintError(errors.New("action not recognized"))
-		return ctx
+		PrintError(s.Out(), errors.New("action not recognized, `help` lists actions"))
+		return
 	}
 
-	ctx = a.Run(ctx, parameters)
-	a.Tips()
-	return ctx
+	a.Run(s, parameters)
+	a.Tips(s.Out())
 }
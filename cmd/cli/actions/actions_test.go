@@ -0,0 +1,174 @@
+package actions_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/toxinu/katago/backends/backendtest"
+	"github.com/toxinu/katago/client"
+	"github.com/toxinu/katago/cmd/cli/actions"
+	"github.com/toxinu/katago/cmd/cli/session"
+	"github.com/toxinu/katago/downloader"
+)
+
+// output is a buffer progress bars can print to from their own goroutine
+type output struct {
+	mutex  sync.Mutex
+	buffer bytes.Buffer
+}
+
+func (o *output) Write(p []byte) (int, error) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	return o.buffer.Write(p)
+}
+
+// take returns what was printed since the last call
+func (o *output) take() string {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	printed := o.buffer.String()
+	o.buffer.Reset()
+	return printed
+}
+
+// newSession returns a Session of Memory backends registered with given
+// slugs, the first one selected, downloading to a temporary directory
+func newSession(t *testing.T, slugs ...string) (*session.Session, map[string]*backendtest.Memory, *output) {
+	images := backendtest.NewImageServer()
+	t.Cleanup(images.Close)
+
+	dir, err := ioutil.TempDir("", "katago-actions-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	memories := map[string]*backendtest.Memory{}
+	registry := session.Downloaders{}
+	for _, slug := range slugs {
+		c := client.NewClient()
+		c.Limiter = nil
+		c.Retry.MaxAttempts = 1
+
+		memories[slug] = backendtest.NewMemory(images)
+		registry[slug] = downloader.New(memories[slug], c)
+		registry[slug].Slug = slug
+	}
+
+	out := &output{}
+	s := session.New(out, registry, registry[slugs[0]])
+	s.SetOutputDir(dir)
+	return s, memories, out
+}
+
+func run(s *session.Session, command string) {
+	fields := strings.Fields(command)
+	actions.Run(s, fields[0], fields[1:])
+}
+
+func TestSearch(t *testing.T) {
+	s, memories, out := newSession(t, "memory")
+	memories["memory"].AddManga("Berserk", 1, 1)
+	memories["memory"].AddManga("Vagabond", 1, 1)
+
+	run(s, "search berserk")
+	if results := s.Results(); len(results) != 1 || results[0].Name != "Berserk" {
+		t.Fatalf("got results %v, want Berserk", results)
+	}
+	if printed := out.take(); !strings.Contains(printed, "Berserk") || strings.Contains(printed, "Vagabond") {
+		t.Errorf("unexpected search output:\n%s", printed)
+	}
+
+	run(s, "search vagabond --author Inoue")
+	printed := out.take()
+	if !strings.Contains(printed, "does not support author filter") || !strings.Contains(printed, "only searches by term") {
+		t.Errorf("unsupported filter not reported:\n%s", printed)
+	}
+	if results := s.Results(); len(results) != 1 || results[0].Name != "Berserk" {
+		t.Errorf("failed search changed results to %v", results)
+	}
+}
+
+func TestSearchAllAndManga(t *testing.T) {
+	s, memories, out := newSession(t, "memory", "other")
+	memories["memory"].AddManga("Berserk", 1, 1)
+	otherBerserk := memories["other"].AddManga("Berserk", 2, 1)
+	memories["other"].AddManga("Vagabond", 1, 1)
+
+	run(s, "search --all berserk")
+	series := s.Series()
+	if len(series) != 1 || strings.Join(series[0].Backends(), ",") != "memory,other" {
+		t.Fatalf("got series %+v, want Berserk on memory and other", series)
+	}
+	if printed := out.take(); !strings.Contains(printed, "memory, other") {
+		t.Errorf("series backends not listed:\n%s", printed)
+	}
+
+	run(s, "manga 0 other")
+	manga, err := s.Manga()
+	if err != nil || manga != otherBerserk {
+		t.Fatalf("got manga %v (%v), want Berserk of other", manga, err)
+	}
+	if d, _ := s.Downloader(); d.Slug != "other" {
+		t.Errorf("got backend %s, want other", d.Slug)
+	}
+	if printed := out.take(); !strings.Contains(printed, "Switched to \"other\" backend") || !strings.Contains(printed, "Name: Berserk") {
+		t.Errorf("unexpected manga output:\n%s", printed)
+	}
+
+	run(s, "manga 0 missing")
+	if printed := out.take(); !strings.Contains(printed, "not on \"missing\" backend") {
+		t.Errorf("missing backend not reported:\n%s", printed)
+	}
+	run(s, "manga 3")
+	if printed := out.take(); !strings.Contains(printed, "index out of range") {
+		t.Errorf("invalid index not reported:\n%s", printed)
+	}
+}
+
+func TestDownload(t *testing.T) {
+	s, memories, out := newSession(t, "memory")
+	manga := memories["memory"].AddManga("Berserk", 3, 2)
+
+	run(s, "download all")
+	if printed := out.take(); !strings.Contains(printed, session.ErrNoManga.Error()) {
+		t.Errorf("missing manga not reported:\n%s", printed)
+	}
+
+	run(s, "search berserk")
+	run(s, "manga 0")
+	run(s, "download 0-1")
+	if printed := out.take(); !strings.Contains(printed, "Chapters to download: 2") || !strings.Contains(printed, "Done!") {
+		t.Errorf("unexpected download output:\n%s", printed)
+	}
+
+	chapters := s.Chapters()
+	if len(chapters) != 3 {
+		t.Fatalf("got %d chapters recorded, want 3", len(chapters))
+	}
+	d := downloader.New(memories["memory"], nil)
+	for index, chapter := range chapters {
+		downloaded := d.Downloaded(manga, chapter, s.OutputDir())
+		if downloaded != (index < 2) {
+			t.Errorf("chapter %d downloaded: %t", index, downloaded)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(downloader.ChapterDir(s.OutputDir(), manga, chapters[0]), "2.png")); err != nil {
+		t.Errorf("page not written: %s", err)
+	}
+
+	run(s, "download new")
+	if printed := out.take(); !strings.Contains(printed, "Chapters to download: 1") {
+		t.Errorf("new chapters not selected:\n%s", printed)
+	}
+	run(s, "download new")
+	if printed := out.take(); !strings.Contains(printed, "No chapter to download") {
+		t.Errorf("downloaded chapters selected again:\n%s", printed)
+	}
+}
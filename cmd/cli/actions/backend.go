@@ -1,41 +1,51 @@
 package actions
 
 import (
-	"context"
 	"errors"
+	"fmt"
+	"io"
 
-	"github.com/toxinu/katago/downloader"
+	"github.com/toxinu/katago/cmd/cli/session"
 )
 
 // Backend represents backend cli action
 type Backend struct{}
 
 // Run implements Action interface
-func (a *Backend) Run(ctx context.Context, parameters []string) context.Context {
-	var (
-		d   *downloader.Downloader
-		err error
-	)
+func (a *Backend) Run(s *session.Session, parameters []string) {
+	out := s.Out()
 
 	if len(parameters) == 0 {
-		PrintError(errors.New("backend name needed"))
-		return ctx
+		PrintError(out, errors.New("backend name needed"))
+		return
 	}
 
-	backend := parameters[0]
-
-	d, err = downloader.NewDownloader(backend)
+	d, err := s.Use(parameters[0])
 	if err != nil {
-		PrintError(err)
-		return ctx
+		PrintError(out, err)
+		return
 	}
-	ctx = ToContext(ctx, "downloader", d)
-
-	return ctx
+	s.SetDownloader(d)
 }
 
 // Tips implements Action interface
-func (*Backend) Tips() {}
+func (*Backend) Tips(out io.Writer) {
+	fmt.Fprintln(out, "\n => Tips: to search for a manga, use `search <term>`")
+}
+
+// Usage implements Action interface
+func (*Backend) Usage() *Usage {
+	return &Usage{
+		Synopsis:    "backend <name>",
+		Description: "Select backend to use",
+		Arguments: []Argument{
+			{Text: "<name>", Description: "backend slug, as listed by `backends`"},
+		},
+		Examples: []string{"backend mangadex"},
+	}
+}
 
 // Help implements Action interface
-func (*Backend) Help() {}
+func (a *Backend) Help(out io.Writer) {
+	PrintUsage(out, a.Usage())
+}
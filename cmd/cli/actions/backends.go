@@ -1,36 +1,91 @@
 package actions
 
 import (
-	"context"
 	"fmt"
+	"io"
+	"sort"
+	"strings"
 
 	"github.com/toxinu/katago/backends"
-	"github.com/toxinu/katago/downloader"
+	"github.com/toxinu/katago/cmd/cli/session"
 )
 
 // Backends represents backends cli action
 type Backends struct{}
 
 // Run implements Action interface
-func (a *Backends) Run(ctx context.Context, parameters []string) context.Context {
-	d := FromContext(ctx, "downloader").(*downloader.Downloader)
+func (a *Backends) Run(s *session.Session, parameters []string) {
+	out := s.Out()
 
-	for slug, backend := range backends.Backends {
+	d, err := s.Downloader()
+	if err != nil {
+		PrintError(out, err)
+		return
+	}
+
+	registered := s.Backends()
+	slugs := make([]string, 0, len(registered))
+	for slug := range registered {
+		slugs = append(slugs, slug)
+	}
+	sort.Strings(slugs)
+
+	for _, slug := range slugs {
+		backend := registered[slug]
 		if backend.Name() == d.Backend.Name() {
-			fmt.Println(slug, "(enabled)")
+			fmt.Fprintln(out, slug, "(enabled)")
 		} else {
-			fmt.Println(slug)
+			fmt.Fprintln(out, slug)
+		}
+		fmt.Fprintln(out, "  - features:", describeCapabilities(backend))
+	}
+
+	if len(backends.LoadErrors) > 0 {
+		fmt.Fprintln(out, "\nSkipped, failed to load:")
+		for _, err := range backends.LoadErrors {
+			fmt.Fprintln(out, "  -", err)
+		}
+	}
+}
+
+// describeCapabilities lists backend capabilities with their details, like
+// "filters (paging), languages (en, fr)"
+func describeCapabilities(b backends.Backend) string {
+	capabilities := backends.Capabilities(b)
+	if len(capabilities) == 0 {
+		return "none"
+	}
+
+	names := make([]string, 0, len(capabilities))
+	for _, capability := range capabilities {
+		switch capability {
+		case backends.CapabilityFilters:
+			names = append(names, fmt.Sprintf("%s (%s)", capability, backends.SupportedFilters(b)))
+		case backends.CapabilityLanguages:
+			languages := b.(backends.Multilingual).ChapterLanguages()
+			names = append(names, fmt.Sprintf("%s (%s)", capability, strings.Join(languages, ", ")))
+		default:
+			names = append(names, string(capability))
 		}
 	}
-	return ctx
+	return strings.Join(names, ", ")
 }
 
 // Tips implements Action interface
-func (*Backends) Tips() {
-	fmt.Println("\n => Tips: to select a backend, use `backend <name>`")
+func (*Backends) Tips(out io.Writer) {
+	fmt.Fprintln(out, "\n => Tips: to select a backend, use `backend <name>`")
+}
+
+// Usage implements Action interface
+func (*Backends) Usage() *Usage {
+	return &Usage{
+		Synopsis:    "backends",
+		Description: "List available backends and their features",
+		Examples:    []string{"backends"},
+	}
 }
 
 // Help implements Action interface
-func (*Backends) Help() {
-	fmt.Println("Show available backends")
+func (a *Backends) Help(out io.Writer) {
+	PrintUsage(out, a.Usage())
 }
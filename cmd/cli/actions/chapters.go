@@ -1,14 +1,13 @@
 package actions
 
 import (
-	"context"
-	"errors"
 	"fmt"
-	"os"
+	"io"
 	"text/tabwriter"
 
 	"github.com/toxinu/katago/backends"
 	"github.com/toxinu/katago/cmd/cli/colors"
+	"github.com/toxinu/katago/cmd/cli/session"
 	"github.com/toxinu/katago/downloader"
 )
 
@@ -16,8 +15,9 @@ import (
 type Chapters struct{}
 
 // Run implements Action interface
-func (a *Chapters) Run(ctx context.Context, parameters []string) context.Context {
+func (a *Chapters) Run(s *session.Session, parameters []string) {
 	var (
+		out      = s.Out()
 		err      error
 		d        *downloader.Downloader
 		manga    *backends.Manga
@@ -25,26 +25,30 @@ func (a *Chapters) Run(ctx context.Context, parameters []string) context.Context
 		columns  = 2
 	)
 
-	if FromContext(ctx, "manga") == nil {
-		PrintError(errors.New("you must select a manga before"))
-		return ctx
+	manga, err = s.Manga()
+	if err != nil {
+		PrintError(out, err)
+		return
+	}
+	d, err = s.Downloader()
+	if err != nil {
+		PrintError(out, err)
+		return
 	}
-
-	manga = FromContext(ctx, "manga").(*backends.Manga)
-	d = FromContext(ctx, "downloader").(*downloader.Downloader)
 
 	chapters, err = d.Backend.Chapters(manga)
 	if err != nil {
-		PrintError(errors.New("cannot retrieve chapters"))
-		return ctx
+		PrintError(out, fmt.Errorf("cannot retrieve chapters: %w", err))
+		return
 	}
+	s.SetChapters(manga, chapters)
 
 	if len(chapters) == 0 {
-		fmt.Println("No chapters found")
-		return ctx
+		fmt.Fprintln(out, "No chapters found")
+		return
 	}
 
-	w := tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', tabwriter.FilterHTML)
+	w := tabwriter.NewWriter(out, 0, 0, 1, ' ', tabwriter.FilterHTML)
 
 	for index := 0; index-columns <= len(chapters); index = index + columns {
 		start, end := index-columns, index
@@ -65,13 +69,23 @@ func (a *Chapters) Run(ctx context.Context, parameters []string) context.Context
 	}
 
 	w.Flush()
-
-	return ctx
 }
 
 // Tips implements action interface
-func (*Chapters) Tips() {
+func (*Chapters) Tips(out io.Writer) {
+	fmt.Fprintln(out, "\n => Tips: to download chapters, use `download <selection>`, `help download` lists selections")
 }
 
-// Help implements action interface
-func (*Chapters) Help() {}
+// Usage implements Action interface
+func (*Chapters) Usage() *Usage {
+	return &Usage{
+		Synopsis:    "chapters",
+		Description: "List selected manga chapters",
+		Examples:    []string{"chapters"},
+	}
+}
+
+// Help implements Action interface
+func (a *Chapters) Help(out io.Writer) {
+	PrintUsage(out, a.Usage())
+}
@@ -4,11 +4,15 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"strconv"
+	"io"
+	"os"
+	"os/signal"
 	"strings"
 
 	"github.com/cheggaaa/pb"
 	"github.com/toxinu/katago/backends"
+	"github.com/toxinu/katago/client"
+	"github.com/toxinu/katago/cmd/cli/session"
 	"github.com/toxinu/katago/downloader"
 )
 
@@ -16,105 +20,141 @@ import (
 type Download struct{}
 
 // Run implements Action interface
-func (a *Download) Run(ctx context.Context, parameters []string) context.Context {
-	var (
-		err                error
-		d                  *downloader.Downloader
-		index              int
-		manga              *backends.Manga
-		chapter            *backends.Chapter
-		chapters           []*backends.Chapter
-		chaptersToDownload []*backends.Chapter
-		indexes            []int
-		start              int
-		end                int
-	)
-
-	if FromContext(ctx, "manga") == nil {
-		PrintError(errors.New("you must select a manga before"))
-		return ctx
-	}
-
-	for _, i := range parameters {
-		if strings.Contains(i, "-") {
-			splittedIndex := strings.Split(i, "-")
-			if len(splittedIndex) > 2 {
-				PrintError(fmt.Errorf("invalid chapter range: \"%s\"", i))
-				return ctx
-			}
-
-			start, err = strconv.Atoi(splittedIndex[0])
-			if err != nil {
-				PrintError(fmt.Errorf("invalid start chapter index (must be integer): \"%s\"", i))
-				return ctx
-			}
+func (a *Download) Run(s *session.Session, parameters []string) {
+	out := s.Out()
 
-			end, err = strconv.Atoi(splittedIndex[1])
-			if err != nil {
-				PrintError(fmt.Errorf("invalid end chapter index (must be integer): \"%s\"", i))
-				return ctx
-			}
+	manga, err := s.Manga()
+	if err != nil {
+		PrintError(out, err)
+		return
+	}
+	d, err := s.Downloader()
+	if err != nil {
+		PrintError(out, err)
+		return
+	}
 
-			if start > end {
-				PrintError(fmt.Errorf("invalid chapters range: \"%s\"", i))
-				return ctx
-			}
-			for y := start; y <= end; y++ {
-				indexes = append(indexes, y)
-			}
-		} else {
-			index, err = strconv.Atoi(i)
-			if err != nil {
-				PrintError(fmt.Errorf("invalid chapter index (must be integer): \"%s\"", i))
-				return ctx
-			}
-			indexes = []int{index}
-		}
+	// A chapter opened from its URL is downloaded when no selection is given
+	if chapter := s.Chapter(); chapter != nil && len(parameters) == 0 {
+		fmt.Fprintln(out, " => Chapter to download:", chapter.Name)
+		a.download(s, d, manga, []*backends.Chapter{chapter})
+		return
 	}
 
-	fmt.Println(" => Chapters to download:", indexes)
+	selection, err := downloader.ParseSelection(strings.Join(parameters, ","))
+	if err != nil {
+		PrintError(out, err)
+		return
+	}
 
-	manga = FromContext(ctx, "manga").(*backends.Manga)
-	d = FromContext(ctx, "downloader").(*downloader.Downloader)
+	chapters, err := d.Backend.Chapters(manga)
+	if err != nil {
+		PrintError(out, fmt.Errorf("cannot retrieve chapters: %w", err))
+		return
+	}
+	s.SetChapters(manga, chapters)
+	if len(chapters) == 0 {
+		PrintError(out, errors.New("cannot retrieve chapters"))
+		return
+	}
 
-	chapters, err = d.Backend.Chapters(manga)
-	if err != nil || len(chapters) == 0 {
-		PrintError(errors.New("cannot retrieve chapters"))
-		return ctx
+	chaptersToDownload, err := selection.Select(chapters, func(chapter *backends.Chapter) bool {
+		return d.Downloaded(manga, chapter, s.OutputDir())
+	})
+	if err != nil {
+		PrintError(out, err)
+		return
+	}
+	if len(chaptersToDownload) == 0 {
+		fmt.Fprintln(out, " => No chapter to download")
+		return
 	}
 
-	bar := pb.StartNew(len(indexes))
+	fmt.Fprintf(out, " => Chapters to download: %d\n", len(chaptersToDownload))
+	a.download(s, d, manga, chaptersToDownload)
+}
 
-	for _, i := range indexes {
-		if i >= len(chapters) {
-			PrintError(fmt.Errorf("chapter \"%d\" is not available", i))
-			return ctx
-		}
+func (a *Download) download(s *session.Session, d *downloader.Downloader, manga *backends.Manga, chaptersToDownload []*backends.Chapter) {
+	out := s.Out()
 
-		chapter = chapters[i]
+	bar := pb.New(len(chaptersToDownload))
+	bar.Output = out
+	bar.Start()
 
-		chaptersToDownload = append(chaptersToDownload, chapter)
-	}
+	ctx, cancel := interruptContext()
+	defer cancel()
 
 	results := make(chan error)
-	d.Download(manga, chaptersToDownload, "./mangas", results)
+	d.WithContext(ctx).Download(manga, chaptersToDownload, s.OutputDir(), results)
 
+	cancelled := false
 	for err := range results {
-		if err != nil {
-			PrintError(err)
+		switch {
+		case errors.Is(err, client.ErrCancelled):
+			// Every chapter left fails once cancelled, it is told once
+			if !cancelled {
+				PrintError(out, err)
+			}
+			cancelled = true
+		case err != nil:
+			PrintError(out, err)
 		}
 		bar.Increment()
 	}
 
 	bar.Finish()
-	fmt.Printf("\nDone! :-)\n")
+	if cancelled {
+		fmt.Fprintf(out, "\nCancelled, downloaded chapters are kept\n")
+		return
+	}
+	fmt.Fprintf(out, "\nDone! :-)\n")
+}
 
-	return ctx
+// interruptContext returns a Context cancelled by Ctrl-C, until its cancel
+// function is called
+func interruptContext() (context.Context, func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt)
+
+	go func() {
+		select {
+		case <-interrupts:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	return ctx, func() {
+		signal.Stop(interrupts)
+		cancel()
+	}
 }
 
 // Tips implements action interface
-func (*Download) Tips() {
+func (*Download) Tips(out io.Writer) {
+	fmt.Fprintln(out, "\n => Tips: `download new` only downloads chapters missing from your library")
 }
 
-// Help implements action interface
-func (*Download) Help() {}
+// Usage implements Action interface
+func (*Download) Usage() *Usage {
+	return &Usage{
+		Synopsis:    "download [selection]",
+		Description: "Download selected manga chapters, or the opened chapter when given no selection",
+		Arguments: []Argument{
+			{Text: "<index>", Description: "chapter index in `chapters`, or a range like 3-7, 50- or -10"},
+			{Text: "c<number>", Description: "chapter number, or a range like c10-20"},
+			{Text: "v<number>", Description: "volume, or a range like v1-2"},
+			{Text: "latest", Description: "last chapter, latest:5 for the last 5"},
+			{Text: "all", Description: "every chapter"},
+			{Text: "new", Description: "chapters not downloaded yet"},
+			{Text: "!<term>", Description: "exclude what the term selects"},
+		},
+		Examples: []string{"download 0-4,10", "download c100-", "download new !v1", "download latest:3"},
+	}
+}
+
+// Help implements Action interface
+func (a *Download) Help(out io.Writer) {
+	PrintUsage(out, a.Usage())
+}
@@ -0,0 +1,104 @@
+package actions
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/toxinu/katago/cmd/cli/colors"
+	"github.com/toxinu/katago/cmd/cli/session"
+)
+
+// Argument documents an action argument or option, Text is what is typed,
+// placeholders are written between angle brackets
+type Argument struct {
+	Text        string
+	Description string
+}
+
+// Usage documents a cli action
+type Usage struct {
+	Synopsis    string
+	Description string
+	Arguments   []Argument
+	Examples    []string
+}
+
+// PrintUsage prints given usage to given writer
+func PrintUsage(out io.Writer, usage *Usage) {
+	fmt.Fprintf(out, "%s%s%s\n\n", colors.Bright, usage.Synopsis, colors.Reset)
+	fmt.Fprintln(out, usage.Description)
+
+	if len(usage.Arguments) > 0 {
+		fmt.Fprintln(out, "\nArguments:")
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		for _, argument := range usage.Arguments {
+			fmt.Fprintf(w, "  %s\t%s\n", argument.Text, argument.Description)
+		}
+		w.Flush()
+	}
+
+	if len(usage.Examples) > 0 {
+		fmt.Fprintln(out, "\nExamples:")
+		for _, example := range usage.Examples {
+			fmt.Fprintln(out, "  "+example)
+		}
+	}
+}
+
+// Names returns actions names, sorted
+func Names() []string {
+	names := make([]string, 0, len(Actions))
+	for name := range Actions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Help represents help cli action
+type Help struct{}
+
+// Run implements Action interface
+func (a *Help) Run(s *session.Session, parameters []string) {
+	out := s.Out()
+
+	if len(parameters) == 0 {
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		for _, name := range Names() {
+			fmt.Fprintf(w, "%s%s%s\t%s\n", colors.Bright, name, colors.Reset, Actions[name].Usage().Description)
+		}
+		w.Flush()
+		return
+	}
+
+	action, ok := Actions[parameters[0]]
+	if !ok {
+		PrintError(out, fmt.Errorf("unknown action \"%s\"", parameters[0]))
+		return
+	}
+
+	action.Help(out)
+	action.Tips(out)
+}
+
+// Usage implements Action interface
+func (*Help) Usage() *Usage {
+	return &Usage{
+		Synopsis:    "help [action]",
+		Description: "List actions, or describe one",
+		Arguments: []Argument{
+			{Text: "<action>", Description: "action to describe, like `download`"},
+		},
+		Examples: []string{"help", "help download"},
+	}
+}
+
+// Tips implements Action interface
+func (*Help) Tips(out io.Writer) {}
+
+// Help implements Action interface
+func (a *Help) Help(out io.Writer) {
+	PrintUsage(out, a.Usage())
+}
@@ -0,0 +1,148 @@
+package actions
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/toxinu/katago/backends"
+	"github.com/toxinu/katago/cmd/cli/colors"
+	"github.com/toxinu/katago/cmd/cli/session"
+	"github.com/toxinu/katago/downloader"
+)
+
+// Latest represents latest cli action
+type Latest struct{}
+
+// Run implements Action interface
+func (a *Latest) Run(s *session.Session, parameters []string) {
+	var (
+		out     = s.Out()
+		d       *downloader.Downloader
+		err     error
+		page    int
+		library bool
+		updates []*backends.Update
+	)
+
+	page, library, err = parseLatest(parameters)
+	if err != nil {
+		PrintError(out, err)
+		return
+	}
+
+	d, err = s.Downloader()
+	if err != nil {
+		PrintError(out, err)
+		return
+	}
+	updates, err = backends.Latest(d.Backend, page)
+	if err != nil {
+		PrintError(out, err)
+		return
+	}
+
+	results := make([]*backends.Manga, 0, len(updates))
+	w := tabwriter.NewWriter(out, 0, 0, 1, ' ', 0)
+
+	if library {
+		local, ok := s.Backends()["local"]
+		if !ok {
+			PrintError(out, errors.New("local library backend is not registered"))
+			return
+		}
+
+		followed, err := backends.FollowUpdates(local, updates)
+		if err != nil {
+			PrintError(out, fmt.Errorf("cannot read local library: %w", err))
+			return
+		}
+
+		for index, update := range followed {
+			status := "new"
+			if update.Downloaded {
+				status = "downloaded"
+			}
+			results = append(results, update.Manga)
+			fmt.Fprintf(w, "%s%d%s\t | %s\t | %s\t | %s\t | %s\n", colors.Bright, index, colors.Reset, update.Manga.Name, update.Chapter.Name, formatDate(update.Date), status)
+		}
+	} else {
+		for index, update := range updates {
+			results = append(results, update.Manga)
+			fmt.Fprintf(w, "%s%d%s\t | %s\t | %s\t | %s\n", colors.Bright, index, colors.Reset, update.Manga.Name, update.Chapter.Name, formatDate(update.Date))
+		}
+	}
+	w.Flush()
+
+	s.SetResults(results)
+
+	if len(updates) > 0 {
+		fmt.Fprintf(out, "\n => Page %d, use `--page %d` for older updates\n", page, page+1)
+	}
+}
+
+// parseLatest reads `--page <n>` and `--library` options
+func parseLatest(parameters []string) (int, bool, error) {
+	page, library := 1, false
+
+	for i := 0; i < len(parameters); i++ {
+		parameter := parameters[i]
+		switch {
+		case parameter == "--library":
+			library = true
+		case parameter == "--page" || strings.HasPrefix(parameter, "--page="):
+			value := strings.TrimPrefix(parameter, "--page=")
+			if parameter == "--page" {
+				if i+1 >= len(parameters) {
+					return 0, false, fmt.Errorf("missing value for option \"%s\"", parameter)
+				}
+				i++
+				value = parameters[i]
+			}
+
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return 0, false, fmt.Errorf("invalid page (must be a positive integer): \"%s\"", value)
+			}
+			page = n
+		default:
+			return 0, false, fmt.Errorf("unknown option \"%s\"", parameter)
+		}
+	}
+
+	return page, library, nil
+}
+
+func formatDate(date time.Time) string {
+	if date.IsZero() {
+		return ""
+	}
+	return date.Local().Format("2006-01-02 15:04")
+}
+
+// Tips implements Action interface
+func (*Latest) Tips(out io.Writer) {
+	fmt.Fprintln(out, "\n => Tips: to select a manga, use `manga <index>`, `latest --library` only shows mangas of your library")
+}
+
+// Usage implements Action interface
+func (*Latest) Usage() *Usage {
+	return &Usage{
+		Synopsis:    "latest [options]",
+		Description: "List chapters recently published on selected backend",
+		Arguments: []Argument{
+			{Text: "--page", Description: "results page, starting at 1"},
+			{Text: "--library", Description: "only list mangas of the local library"},
+		},
+		Examples: []string{"latest", "latest --library --page 2"},
+	}
+}
+
+// Help implements Action interface
+func (a *Latest) Help(out io.Writer) {
+	PrintUsage(out, a.Usage())
+}
@@ -1,57 +1,106 @@
 package actions
 
 import (
-	"context"
 	"errors"
 	"fmt"
+	"io"
 	"strconv"
+	"strings"
 
 	"github.com/toxinu/katago/backends"
+	"github.com/toxinu/katago/cmd/cli/session"
+	"github.com/toxinu/katago/downloader"
 )
 
 // Manga represents manga cli action
 type Manga struct{}
 
 // Run implements Action interface
-func (a *Manga) Run(ctx context.Context, parameters []string) context.Context {
+func (a *Manga) Run(s *session.Session, parameters []string) {
 	var (
+		out     = s.Out()
 		err     error
 		index   int
+		d       *downloader.Downloader
 		manga   *backends.Manga
-		results []*backends.Manga
+		results = s.Results()
 	)
 
-	results = FromContext(ctx, "results").([]*backends.Manga)
-
 	if len(parameters) == 0 {
-		PrintError(errors.New("manga index needed"))
-		return ctx
+		PrintError(out, errors.New("manga index needed"))
+		return
 	}
 
 	index, err = strconv.Atoi(parameters[0])
 	if err != nil {
-		PrintError(errors.New("invalid index (must be integer)"))
-		return ctx
+		PrintError(out, errors.New("invalid index (must be integer)"))
+		return
 	}
 
-	if index >= len(results) {
-		PrintError(errors.New("index out of range"))
-		return ctx
+	if index < 0 || index >= len(results) {
+		PrintError(out, errors.New("index out of range"))
+		return
 	}
 
 	manga = results[index]
-	ctx = ToContext(ctx, "manga", manga)
 
-	fmt.Printf("I confirm you that you just select this manga:\n\n")
-	fmt.Println("Name:", manga.Name)
-	fmt.Println("Author:", manga.Author)
-	fmt.Println("Genre:", manga.Genre)
+	// After `search --all`, results come from several backends
+	if series := s.Series(); index < len(series) {
+		source := series[index].Sources[0]
+		if len(parameters) > 1 {
+			source = series[index].Source(parameters[1])
+			if source == nil {
+				PrintError(out, fmt.Errorf("this manga is not on \"%s\" backend (available on %s)", parameters[1], strings.Join(series[index].Backends(), ", ")))
+				return
+			}
+		}
+
+		d, err = s.Use(source.Backend)
+		if err != nil {
+			PrintError(out, err)
+			return
+		}
+		s.SetDownloader(d)
+		manga = source.Manga
+		fmt.Fprintf(out, "Switched to \"%s\" backend\n\n", source.Backend)
+	}
+
+	s.SetManga(manga, nil)
+	s.PrefetchChapters()
+
+	fmt.Fprintf(out, "I confirm you that you just select this manga:\n\n")
+	fmt.Fprintln(out, "Name:", manga.Name)
+	fmt.Fprintln(out, "Author:", manga.Author)
+	fmt.Fprintln(out, "Genre:", manga.Genre)
 
-	return ctx
+	d, err = s.Downloader()
+	if err == nil && backends.Has(d.Backend, backends.CapabilityCovers) {
+		cover, err := backends.CoverURL(d.Backend, manga)
+		if err == nil {
+			fmt.Fprintln(out, "Cover:", cover)
+		}
+	}
 }
 
 // Tips implements Action interface
-func (*Manga) Tips() {}
+func (*Manga) Tips(out io.Writer) {
+	fmt.Fprintln(out, "\n => Tips: to list its chapters, use `chapters`, to download some, use `download <selection>`")
+}
+
+// Usage implements Action interface
+func (*Manga) Usage() *Usage {
+	return &Usage{
+		Synopsis:    "manga <index> [backend]",
+		Description: "Select manga with index",
+		Arguments: []Argument{
+			{Text: "<index>", Description: "manga index in search or latest results"},
+			{Text: "<backend>", Description: "source to use after `search --all`, the first one otherwise"},
+		},
+		Examples: []string{"manga 0", "manga 2 mangadex"},
+	}
+}
 
 // Help implements Action interface
-func (*Manga) Help() {}
+func (a *Manga) Help(out io.Writer) {
+	PrintUsage(out, a.Usage())
+}
@@ -0,0 +1,114 @@
+package actions
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/toxinu/katago/backends"
+	"github.com/toxinu/katago/cmd/cli/session"
+	"github.com/toxinu/katago/downloader"
+)
+
+// Open represents open cli action
+type Open struct{}
+
+// Run implements Action interface
+func (a *Open) Run(s *session.Session, parameters []string) {
+	var (
+		out  = s.Out()
+		err  error
+		d    *downloader.Downloader
+		u    *url.URL
+		slug string
+		link *backends.Link
+	)
+
+	if len(parameters) == 0 {
+		PrintError(out, errors.New("URL needed"))
+		return
+	}
+
+	u, err = parseLink(strings.Join(parameters, " "))
+	if err != nil {
+		PrintError(out, err)
+		return
+	}
+
+	slug, link, err = backends.ResolveURL(s.Backends(), u)
+	if err != nil {
+		PrintError(out, err)
+		return
+	}
+
+	d, err = s.Downloader()
+	if err != nil || d.Slug != slug {
+		d, err = s.Use(slug)
+		if err != nil {
+			PrintError(out, err)
+			return
+		}
+		s.SetDownloader(d)
+	}
+
+	s.SetResults([]*backends.Manga{link.Manga})
+	s.SetManga(link.Manga, link.Chapter)
+	s.PrefetchChapters()
+
+	fmt.Fprintf(out, "Opened on \"%s\" backend:\n\n", slug)
+	fmt.Fprintln(out, "Name:", link.Manga.Name)
+	fmt.Fprintln(out, "Author:", link.Manga.Author)
+	if link.Chapter != nil {
+		fmt.Fprintln(out, "Chapter:", link.Chapter.Name)
+	}
+	if link.Page != nil {
+		fmt.Fprintln(out, "Page:", link.Page.URL)
+	}
+}
+
+// parseLink reads an URL, paths of existing files are file URLs and URLs
+// without scheme are http ones
+func parseLink(raw string) (*url.URL, error) {
+	if _, err := os.Stat(raw); err == nil {
+		name, err := filepath.Abs(raw)
+		if err != nil {
+			return nil, err
+		}
+		return &url.URL{Scheme: "file", Path: filepath.ToSlash(name)}, nil
+	}
+
+	u, err := url.Parse(raw)
+	if err == nil && len(u.Scheme) == 0 {
+		u, err = url.Parse("http://" + raw)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL: \"%s\"", raw)
+	}
+	return u, nil
+}
+
+// Tips implements Action interface
+func (*Open) Tips(out io.Writer) {
+	fmt.Fprintln(out, "\n => Tips: to download an opened chapter, use `download` without selection")
+}
+
+// Usage implements Action interface
+func (*Open) Usage() *Usage {
+	return &Usage{
+		Synopsis:    "open <url>",
+		Description: "Select a manga, chapter or page from its URL",
+		Arguments: []Argument{
+			{Text: "<url>", Description: "manga, chapter or page link, or a path to the local library"},
+		},
+		Examples: []string{"open https://mangadex.org/title/<id>", "open mangas/Berserk/c001.cbz"},
+	}
+}
+
+// Help implements Action interface
+func (a *Open) Help(out io.Writer) {
+	PrintUsage(out, a.Usage())
+}
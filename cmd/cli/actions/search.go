@@ -1,14 +1,18 @@
 package actions
 
 import (
-	"context"
+	"errors"
 	"fmt"
-	"os"
+	"io"
+	"sort"
+	"strconv"
 	"strings"
 	"text/tabwriter"
+	"time"
 
 	"github.com/toxinu/katago/backends"
 	"github.com/toxinu/katago/cmd/cli/colors"
+	"github.com/toxinu/katago/cmd/cli/session"
 	"github.com/toxinu/katago/downloader"
 )
 
@@ -16,36 +20,227 @@ import (
 type Search struct{}
 
 // Run implements action interface
-func (a *Search) Run(ctx context.Context, parameters []string) context.Context {
+func (a *Search) Run(s *session.Session, parameters []string) {
 	var (
+		out     = s.Out()
 		d       *downloader.Downloader
 		err     error
+		all     bool
+		timeout time.Duration
+		query   *backends.Query
 		results []*backends.Manga
 	)
 
-	d = FromContext(ctx, "downloader").(*downloader.Downloader)
-	results, err = d.Backend.Search(strings.Join(parameters, " "))
+	all, timeout, parameters, err = parseSearchAll(parameters)
 	if err != nil {
-		PrintError(err)
-		return ctx
+		PrintError(out, err)
+		return
 	}
 
-	ctx = ToContext(ctx, "results", results)
+	query, err = parseQuery(parameters)
+	if err != nil {
+		PrintError(out, err)
+		return
+	}
+
+	if all {
+		a.runAll(s, query, timeout)
+		return
+	}
+
+	d, err = s.Downloader()
+	if err != nil {
+		PrintError(out, err)
+		return
+	}
+	results, err = backends.Search(d.Backend, query)
+	if errors.Is(err, backends.ErrUnsupportedFilter) {
+		PrintError(out, err)
+		if backends.Has(d.Backend, backends.CapabilityFilters) {
+			fmt.Fprintln(out, " => Supported filters:", backends.SupportedFilters(d.Backend))
+		} else {
+			fmt.Fprintln(out, " => This backend only searches by term")
+		}
+		return
+	}
+	if err != nil {
+		PrintError(out, err)
+		return
+	}
+
+	s.SetResults(results)
 
-	w := tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', 0)
+	w := tabwriter.NewWriter(out, 0, 0, 1, ' ', 0)
 
 	for index, result := range results {
 		fmt.Fprintf(w, "%s%d%s\t | %s\t | %s\n", colors.Bright, index, colors.Reset, result.Name, result.Author)
 	}
 	w.Flush()
 
-	return ctx
+	if len(results) > 0 && backends.SupportedFilters(d.Backend).Paging {
+		fmt.Fprintf(out, "\n => Page %d, use `--page %d` for more results\n", query.Page, query.Page+1)
+	}
+}
+
+// runAll searches every registered backend, results are one manga per series
+// and the series let `manga` pick the backend to use
+func (a *Search) runAll(s *session.Session, query *backends.Query, timeout time.Duration) {
+	out := s.Out()
+
+	series, errs := backends.SearchAll(s.Backends(), query, timeout)
+	s.SetSeries(series)
+
+	w := tabwriter.NewWriter(out, 0, 0, 1, ' ', 0)
+
+	for index, serie := range series {
+		fmt.Fprintf(w, "%s%d%s\t | %s\t | %s\t | %s\n", colors.Bright, index, colors.Reset, serie.Name, serie.Author, strings.Join(serie.Backends(), ", "))
+	}
+	w.Flush()
+
+	slugs := make([]string, 0, len(errs))
+	for slug := range errs {
+		slugs = append(slugs, slug)
+	}
+	sort.Strings(slugs)
+
+	if len(slugs) > 0 {
+		fmt.Fprintln(out, "\n => Skipped backends:")
+		for _, slug := range slugs {
+			fmt.Fprintf(out, "    %s: %s\n", slug, errs[slug])
+		}
+	}
+}
+
+// parseSearchAll reads the `--all` and `--timeout <duration>` options and
+// returns the other parameters
+func parseSearchAll(parameters []string) (bool, time.Duration, []string, error) {
+	var (
+		all        bool
+		hasTimeout bool
+		timeout    = backends.SearchAllTimeout
+		rest       = make([]string, 0, len(parameters))
+	)
+
+	for i := 0; i < len(parameters); i++ {
+		parameter := parameters[i]
+		switch {
+		case parameter == "--all":
+			all = true
+		case parameter == "--timeout" || strings.HasPrefix(parameter, "--timeout="):
+			value := strings.TrimPrefix(parameter, "--timeout=")
+			if parameter == "--timeout" {
+				if i+1 >= len(parameters) {
+					return false, 0, nil, fmt.Errorf("missing value for option \"%s\"", parameter)
+				}
+				i++
+				value = parameters[i]
+			}
+
+			duration, err := time.ParseDuration(value)
+			if err != nil || duration <= 0 {
+				return false, 0, nil, fmt.Errorf("invalid timeout (must be a duration like 10s): \"%s\"", value)
+			}
+			timeout, hasTimeout = duration, true
+		default:
+			rest = append(rest, parameter)
+		}
+	}
+
+	if hasTimeout && !all {
+		return false, 0, nil, fmt.Errorf("option \"--timeout\" needs \"--all\"")
+	}
+	return all, timeout, rest, nil
+}
+
+// parseQuery reads the search term and options like `--genre action`
+func parseQuery(parameters []string) (*backends.Query, error) {
+	query := backends.NewQuery("")
+	terms := make([]string, 0, len(parameters))
+
+	for i := 0; i < len(parameters); i++ {
+		parameter := parameters[i]
+		if !strings.HasPrefix(parameter, "--") {
+			terms = append(terms, parameter)
+			continue
+		}
+
+		name, value := strings.TrimPrefix(parameter, "--"), ""
+		if index := strings.Index(name, "="); index >= 0 {
+			name, value = name[:index], name[index+1:]
+		} else {
+			if i+1 >= len(parameters) {
+				return nil, fmt.Errorf("missing value for option \"%s\"", parameter)
+			}
+			i++
+			value = parameters[i]
+		}
+
+		switch name {
+		case "page":
+			page, err := strconv.Atoi(value)
+			if err != nil || page < 1 {
+				return nil, fmt.Errorf("invalid page (must be a positive integer): \"%s\"", value)
+			}
+			query.Page = page
+		case "sort":
+			switch value {
+			case backends.SortRelevance, backends.SortPopularity, backends.SortLatest, backends.SortTitle:
+				query.Sort = value
+			default:
+				return nil, fmt.Errorf("invalid sort (must be relevance, popularity, latest or title): \"%s\"", value)
+			}
+		case "genre":
+			query.IncludeGenres = append(query.IncludeGenres, value)
+		case "exclude-genre":
+			query.ExcludeGenres = append(query.ExcludeGenres, value)
+		case "status":
+			switch value {
+			case backends.StatusOngoing, backends.StatusCompleted:
+				query.Status = value
+			default:
+				return nil, fmt.Errorf("invalid status (must be ongoing or completed): \"%s\"", value)
+			}
+		case "author":
+			query.Author = value
+		default:
+			return nil, fmt.Errorf("unknown option \"%s\"", parameter)
+		}
+	}
+
+	query.Term = strings.Join(terms, " ")
+	return query, nil
 }
 
 // Tips implements action interface
-func (*Search) Tips() {
-	fmt.Println("\n => Tips: to select a manga, use `manga <index>`")
+func (*Search) Tips(out io.Writer) {
+	fmt.Fprintln(out, "\n => Tips: to select a manga, use `manga <index>`, after `search --all` use `manga <index> <backend>` to pick its source")
 }
 
-// Help implements action interface
-func (*Search) Help() {}
+// Usage implements Action interface
+func (*Search) Usage() *Usage {
+	return &Usage{
+		Synopsis:    "search [term] [options]",
+		Description: "Search for a manga on selected backend, or every backend with --all",
+		Arguments: []Argument{
+			{Text: "<term>", Description: "words to look for in titles"},
+			{Text: "--page", Description: "results page, starting at 1"},
+			{Text: "--sort", Description: "relevance, popularity, latest or title"},
+			{Text: "--genre", Description: "genre to include, repeatable"},
+			{Text: "--exclude-genre", Description: "genre to exclude, repeatable"},
+			{Text: "--status", Description: "ongoing or completed"},
+			{Text: "--author", Description: "author name"},
+			{Text: "--all", Description: "search every backend at once"},
+			{Text: "--timeout", Description: "how long to wait for each backend with --all, like 5s"},
+		},
+		Examples: []string{
+			"search one piece",
+			"search --sort latest --status ongoing --genre action",
+			"search --all berserk --timeout 5s",
+		},
+	}
+}
+
+// Help implements Action interface
+func (a *Search) Help(out io.Writer) {
+	PrintUsage(out, a.Usage())
+}
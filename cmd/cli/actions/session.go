@@ -0,0 +1,95 @@
+package actions
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/toxinu/katago/cmd/cli/session"
+	"github.com/toxinu/katago/config"
+)
+
+// Session represents session cli action
+type Session struct{}
+
+// Run implements Action interface
+func (a *Session) Run(s *session.Session, parameters []string) {
+	out := s.Out()
+
+	if len(parameters) == 0 {
+		PrintError(out, errors.New("session command needed: save, restore or clear"))
+		return
+	}
+
+	path := config.SessionPath()
+	if len(parameters) > 1 {
+		path = parameters[1]
+	}
+
+	switch parameters[0] {
+	case "save":
+		err := s.Save(path)
+		if err != nil {
+			PrintError(out, err)
+			return
+		}
+		fmt.Fprintln(out, "Session saved to", path)
+	case "restore":
+		err := s.Restore(path)
+		if err != nil {
+			PrintError(out, err)
+			return
+		}
+		fmt.Fprintln(out, "Session restored from", path)
+		PrintSession(s)
+	case "clear":
+		err := os.Remove(path)
+		if err != nil && !os.IsNotExist(err) {
+			PrintError(out, err)
+			return
+		}
+		s.Clear()
+		fmt.Fprintln(out, "Session cleared")
+	default:
+		PrintError(out, fmt.Errorf("unknown session command \"%s\"", parameters[0]))
+	}
+}
+
+// PrintSession prints the selected backend, manga and chapter
+func PrintSession(s *session.Session) {
+	out := s.Out()
+
+	if d, err := s.Downloader(); err == nil {
+		fmt.Fprintln(out, "Backend:", d.Slug)
+	}
+	if manga, err := s.Manga(); err == nil {
+		fmt.Fprintln(out, "Manga:", manga.Name)
+	}
+	if chapter := s.Chapter(); chapter != nil {
+		fmt.Fprintln(out, "Chapter:", chapter.Name)
+	}
+}
+
+// Usage implements Action interface
+func (*Session) Usage() *Usage {
+	return &Usage{
+		Synopsis:    "session <save|restore|clear> [file]",
+		Description: "Save or restore the selected backend, results, manga and chapter",
+		Arguments: []Argument{
+			{Text: "save", Description: "save the session, done after every command"},
+			{Text: "restore", Description: "restore the session, done on launch"},
+			{Text: "clear", Description: "forget results and selected manga, next launch starts afresh"},
+			{Text: "<file>", Description: "session file, the one restored on launch by default"},
+		},
+		Examples: []string{"session save berserk.json", "session restore berserk.json"},
+	}
+}
+
+// Tips implements Action interface
+func (*Session) Tips(out io.Writer) {}
+
+// Help implements Action interface
+func (a *Session) Help(out io.Writer) {
+	PrintUsage(out, a.Usage())
+}
@@ -1,27 +1,22 @@
 package main
 
 import (
-	"context"
 	"fmt"
+	"os"
 	"strings"
 
 	prompt "github.com/c-bata/go-prompt"
 	"github.com/toxinu/katago/backends"
 	"github.com/toxinu/katago/cmd/cli/actions"
+	"github.com/toxinu/katago/cmd/cli/session"
+	"github.com/toxinu/katago/config"
 	"github.com/toxinu/katago/downloader"
 )
 
-var ctx = context.Background()
+// defaultBackend is the backend selected when no session is restored
+const defaultBackend = "mangafox"
 
-// FromContext returns key's value from given Context
-func FromContext(ctx context.Context, key string) interface{} {
-	return ctx.Value(actions.Key(key))
-}
-
-// ToContext sets given value to Context's key
-func ToContext(ctx context.Context, key string, value interface{}) context.Context {
-	return context.WithValue(ctx, actions.Key(key), value)
-}
+var current *session.Session
 
 func executor(in string) {
 	splitted := strings.Fields(in)
@@ -31,37 +26,52 @@ func executor(in string) {
 	action := splitted[0]
 	parameters := splitted[1:len(splitted)]
 
-	ctx = actions.Run(ctx, action, parameters)
-}
+	err := appendHistory(config.HistoryPath(), strings.Join(splitted, " "))
+	if err != nil {
+		actions.PrintError(os.Stdout, fmt.Errorf("cannot save history: %w", err))
+	}
+
+	actions.Run(current, action, parameters)
+	cache.record(action, parameters)
 
-func completer(d prompt.Document) []prompt.Suggest {
-	s := []prompt.Suggest{
-		{Text: "backends", Description: "List available backends"},
-		{Text: "backend", Description: "Select backend to use"},
-		{Text: "search", Description: "Search for a manga on selected backend"},
-		{Text: "manga", Description: "Select manga with index"},
-		{Text: "download", Description: "Download selected manga"},
-		{Text: "chapters", Description: "List selected manga chapters"},
+	// The prompt exits without notice, the session is saved after every
+	// command but the ones managing it, and not once cleared until a new
+	// selection
+	if action != "session" && !current.Cleared() {
+		err = current.Save(config.SessionPath())
+		if err != nil {
+			actions.PrintError(os.Stdout, fmt.Errorf("cannot save session: %w", err))
+		}
 	}
-	return prompt.FilterHasPrefix(s, d.GetWordBeforeCursor(), true)
 }
 
 func main() {
-	ctx = ToContext(ctx, "backend", "mangafox")
-
-	d, err := downloader.NewDownloader(FromContext(ctx, "backend").(string))
+	d, err := downloader.NewDownloader(defaultBackend)
 	if err != nil {
 		panic(err)
 	}
+	current = session.New(os.Stdout, session.Registered, d)
 
-	ctx = ToContext(ctx, "downloader", d)
-	ctx = ToContext(ctx, "manga", nil)
-	ctx = ToContext(ctx, "prompt", prompt.New(executor, completer, prompt.OptionPrefix(">>> "), prompt.OptionTitle("katago")))
-	ctx = ToContext(ctx, "results", []*backends.Manga{})
+	history := readHistory(config.HistoryPath())
+	cache.seed(history)
+	p := prompt.New(executor, completer, prompt.OptionPrefix(">>> "), prompt.OptionTitle("katago"), prompt.OptionHistory(history))
 
 	fmt.Println("Welcome,")
-	fmt.Printf("I have already selected \"%s\" backend for you :)\n\n", FromContext(ctx, "backend"))
-	fmt.Println("You can now `search` for a manga.")
+	err = current.Restore(config.SessionPath())
+	switch {
+	case err == nil:
+		current.PrefetchChapters()
+		fmt.Println("Your last session is back:")
+		actions.PrintSession(current)
+		fmt.Println()
+	case os.IsNotExist(err):
+		fmt.Printf("I have already selected \"%s\" backend for you :)\n\n", defaultBackend)
+	default:
+		actions.PrintError(os.Stdout, fmt.Errorf("cannot restore last session: %w", err))
+		fmt.Printf("I have selected \"%s\" backend for you :)\n\n", defaultBackend)
+	}
+	fmt.Println("You can now `search` for a manga, `help` lists every action.")
 
-	FromContext(ctx, "prompt").(*prompt.Prompt).Run()
+	p.Run()
+	backends.Close()
 }
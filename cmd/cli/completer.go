@@ -0,0 +1,227 @@
+package main
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	prompt "github.com/c-bata/go-prompt"
+	"github.com/toxinu/katago/backends"
+	"github.com/toxinu/katago/cmd/cli/actions"
+)
+
+// maxSearches is how many search terms completions remember
+const maxSearches = 20
+
+// completions caches what argument completions would otherwise fetch on
+// every key stroke
+type completions struct {
+	// library lists local library titles, nil until read
+	library []string
+	// searches are the previous search terms, most recent first
+	searches []string
+}
+
+var cache = &completions{}
+
+// record remembers what given command changes for completions
+func (c *completions) record(action string, parameters []string) {
+	switch action {
+	case "search":
+		term := make([]string, 0, len(parameters))
+		for _, parameter := range parameters {
+			if strings.HasPrefix(parameter, "--") {
+				break
+			}
+			term = append(term, parameter)
+		}
+		if len(term) == 0 {
+			return
+		}
+
+		searches := []string{strings.Join(term, " ")}
+		for _, search := range c.searches {
+			if search != searches[0] && len(searches) < maxSearches {
+				searches = append(searches, search)
+			}
+		}
+		c.searches = searches
+	case "download":
+		c.library = nil
+	}
+}
+
+// seed remembers what the commands of given history, oldest first, change
+// for completions
+func (c *completions) seed(history []string) {
+	for _, line := range history {
+		words := strings.Fields(line)
+		if len(words) > 0 {
+			c.record(words[0], words[1:])
+		}
+	}
+}
+
+// libraryTitles returns local library manga names, read once until the next
+// download
+func (c *completions) libraryTitles() []string {
+	if c.library != nil {
+		return c.library
+	}
+
+	c.library = []string{}
+	local, ok := current.Backends()["local"]
+	if !ok {
+		return c.library
+	}
+	mangas, err := local.Search(backends.NewQuery(""))
+	if err != nil {
+		return c.library
+	}
+	for _, manga := range mangas {
+		c.library = append(c.library, manga.Name)
+	}
+	sort.Strings(c.library)
+	return c.library
+}
+
+func completer(d prompt.Document) []prompt.Suggest {
+	words := strings.Fields(d.TextBeforeCursor())
+	word := d.GetWordBeforeCursor()
+
+	if len(words) == 0 || (len(words) == 1 && len(word) > 0) {
+		return prompt.FilterHasPrefix(actionSuggestions(), word, true)
+	}
+
+	action, ok := actions.Actions[words[0]]
+	if !ok {
+		return nil
+	}
+
+	// arguments are the ones before the word being typed
+	arguments := words[1:]
+	if len(word) > 0 {
+		arguments = arguments[:len(arguments)-1]
+	}
+
+	var s []prompt.Suggest
+	switch words[0] {
+	case "help":
+		s = actionSuggestions()
+	case "backend":
+		s = backendSuggestions()
+	case "manga":
+		s = mangaSuggestions(arguments)
+	case "search":
+		return searchSuggestions(d.TextBeforeCursor(), word)
+	case "download":
+		return downloadSuggestions(action, word)
+	}
+
+	s = append(s, argumentSuggestions(action)...)
+	return prompt.FilterHasPrefix(s, word, true)
+}
+
+// actionSuggestions offers action names
+func actionSuggestions() []prompt.Suggest {
+	s := []prompt.Suggest{}
+	for _, name := range actions.Names() {
+		s = append(s, prompt.Suggest{Text: name, Description: actions.Actions[name].Usage().Description})
+	}
+	return s
+}
+
+// backendSuggestions offers registered backend slugs
+func backendSuggestions() []prompt.Suggest {
+	registered := current.Backends()
+	slugs := make([]string, 0, len(registered))
+	for slug := range registered {
+		slugs = append(slugs, slug)
+	}
+	sort.Strings(slugs)
+
+	s := make([]prompt.Suggest, 0, len(slugs))
+	for _, slug := range slugs {
+		s = append(s, prompt.Suggest{Text: slug, Description: registered[slug].Name()})
+	}
+	return s
+}
+
+// mangaSuggestions offers results indexes, then the backends carrying the
+// chosen series after `search --all`
+func mangaSuggestions(arguments []string) []prompt.Suggest {
+	s := []prompt.Suggest{}
+	if len(arguments) == 0 {
+		for index, manga := range current.Results() {
+			s = append(s, prompt.Suggest{Text: strconv.Itoa(index), Description: manga.Name})
+		}
+		return s
+	}
+
+	series := current.Series()
+	index, err := strconv.Atoi(arguments[0])
+	if len(arguments) > 1 || err != nil || index < 0 || index >= len(series) {
+		return s
+	}
+	for _, source := range series[index].Sources {
+		s = append(s, prompt.Suggest{Text: source.Backend, Description: source.Manga.Name})
+	}
+	return s
+}
+
+// searchSuggestions offers local library titles and previous search terms
+// matching the whole term typed so far, suggestions only complete the word
+// being typed since it is the one the prompt replaces
+func searchSuggestions(text string, word string) []prompt.Suggest {
+	term := strings.TrimLeft(strings.TrimPrefix(strings.TrimLeft(text, " "), "search"), " ")
+	if strings.Contains(term, "--") {
+		return prompt.FilterHasPrefix(argumentSuggestions(actions.Actions["search"]), word, true)
+	}
+
+	seen := map[string]bool{}
+	s := []prompt.Suggest{}
+	add := func(title string, description string) {
+		key := strings.ToLower(title)
+		if seen[key] || !strings.HasPrefix(key, strings.ToLower(term)) || len(title) < len(term) {
+			return
+		}
+		seen[key] = true
+		s = append(s, prompt.Suggest{Text: title[len(term)-len(word):], Description: description})
+	}
+
+	for _, search := range cache.searches {
+		add(search, "previous search")
+	}
+	for _, title := range cache.libraryTitles() {
+		add(title, "in your library")
+	}
+	return s
+}
+
+// downloadSuggestions offers selection keywords and selected manga chapter
+// indexes once fetched, after the commas of the term being typed
+func downloadSuggestions(action actions.Action, word string) []prompt.Suggest {
+	prefix, last := "", word
+	if i := strings.LastIndex(word, ","); i >= 0 {
+		prefix, last = word[:i+1], word[i+1:]
+	}
+
+	s := argumentSuggestions(action)
+	for index, chapter := range current.Chapters() {
+		s = append(s, prompt.Suggest{Text: strconv.Itoa(index), Description: chapter.Name})
+	}
+
+	s = prompt.FilterHasPrefix(s, last, true)
+	for i := range s {
+		s[i].Text = prefix + s[i].Text
+	}
+	return s
+}
+
+func argumentSuggestions(action actions.Action) []prompt.Suggest {
+	s := []prompt.Suggest{}
+	for _, argument := range action.Usage().Arguments {
+		s = append(s, prompt.Suggest{Text: argument.Text, Description: argument.Description})
+	}
+	return s
+}
@@ -0,0 +1,16 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCompletionsSeed(t *testing.T) {
+	c := &completions{}
+	c.seed([]string{"search berserk", "manga 0", "search vagabond --author inoue", "search", "search berserk"})
+
+	want := []string{"berserk", "vagabond"}
+	if !reflect.DeepEqual(c.searches, want) {
+		t.Errorf("got searches %q, want %q", c.searches, want)
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// maxHistory is how many commands the history file keeps
+const maxHistory = 1000
+
+// readHistory returns the last commands of given history file, oldest
+// first, the file is trimmed when longer than maxHistory
+func readHistory(path string) []string {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	if len(lines) == 1 && len(lines[0]) == 0 {
+		return nil
+	}
+	if len(lines) > maxHistory {
+		lines = lines[len(lines)-maxHistory:]
+		ioutil.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0600)
+	}
+	return lines
+}
+
+// appendHistory adds given command to the history file
+func appendHistory(path string, line string) error {
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.WriteString(line + "\n")
+	return err
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func tempHistory(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "katago-history-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return filepath.Join(dir, "config", "history")
+}
+
+func TestAppendHistory(t *testing.T) {
+	path := tempHistory(t)
+	if history := readHistory(path); history != nil {
+		t.Errorf("got %q from a missing history, want none", history)
+	}
+
+	for _, line := range []string{"search berserk", "manga 0"} {
+		err := appendHistory(path, line)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := []string{"search berserk", "manga 0"}
+	if history := readHistory(path); !reflect.DeepEqual(history, want) {
+		t.Errorf("got %q, want %q", history, want)
+	}
+}
+
+func TestReadHistoryTrims(t *testing.T) {
+	path := tempHistory(t)
+	var lines []string
+	for i := 0; i < maxHistory+5; i++ {
+		lines = append(lines, fmt.Sprintf("chapter %d", i))
+	}
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ioutil.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	history := readHistory(path)
+	if !reflect.DeepEqual(history, lines[5:]) {
+		t.Fatalf("got %d commands from %q to %q, want the last %d", len(history), history[0], history[len(history)-1], maxHistory)
+	}
+
+	err = appendHistory(path, "download new")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := strings.Join(append(lines[5:], "download new"), "\n") + "\n"
+	if string(data) != want {
+		t.Errorf("history file holds %d lines, want the trimmed history and the new command", strings.Count(string(data), "\n"))
+	}
+}
+
+func TestReadEmptyHistory(t *testing.T) {
+	path := tempHistory(t)
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ioutil.WriteFile(path, nil, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if history := readHistory(path); history != nil {
+		t.Errorf("got %q from an empty history, want none", history)
+	}
+}
@@ -0,0 +1,226 @@
+package session
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"github.com/toxinu/katago/backends"
+)
+
+// savedManga is a manga as saved in a session file
+type savedManga struct {
+	ID     string `json:"id,omitempty"`
+	Name   string `json:"name"`
+	Slug   string `json:"slug,omitempty"`
+	Author string `json:"author,omitempty"`
+	Genre  string `json:"genre,omitempty"`
+	URL    string `json:"url"`
+}
+
+// savedChapter is a chapter as saved in a session file
+type savedChapter struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+// savedSource is a series source as saved in a session file
+type savedSource struct {
+	Backend string      `json:"backend"`
+	Manga   *savedManga `json:"manga"`
+}
+
+// savedSeries is a series as saved in a session file
+type savedSeries struct {
+	Name    string         `json:"name"`
+	Author  string         `json:"author,omitempty"`
+	Sources []*savedSource `json:"sources"`
+}
+
+// file is a Session as saved in a session file, results are saved as series
+// when they come from several backends
+type file struct {
+	Backend string         `json:"backend"`
+	Results []*savedManga  `json:"results,omitempty"`
+	Series  []*savedSeries `json:"series,omitempty"`
+	Manga   *savedManga    `json:"manga,omitempty"`
+	Chapter *savedChapter  `json:"chapter,omitempty"`
+}
+
+// Save writes the selected backend, results or series, manga and chapter to
+// given path
+func (s *Session) Save(path string) error {
+	d, err := s.Downloader()
+	if err != nil {
+		return err
+	}
+
+	f := &file{Backend: d.Slug}
+	if s.series != nil {
+		for _, series := range s.series {
+			saved := &savedSeries{Name: series.Name, Author: series.Author}
+			for _, source := range series.Sources {
+				saved.Sources = append(saved.Sources, &savedSource{Backend: source.Backend, Manga: saveManga(source.Manga)})
+			}
+			f.Series = append(f.Series, saved)
+		}
+	} else {
+		for _, manga := range s.results {
+			f.Results = append(f.Results, saveManga(manga))
+		}
+	}
+	if s.manga != nil {
+		f.Manga = saveManga(s.manga)
+	}
+	if s.chapter != nil {
+		f.Chapter = &savedChapter{Name: s.chapter.Name, URL: urlString(s.chapter.URL)}
+	}
+
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return err
+	}
+
+	// The session is written aside then moved in place, a crash while
+	// writing leaves the previous one
+	file, err := ioutil.TempFile(filepath.Dir(path), ".tmp-")
+	if err != nil {
+		return err
+	}
+
+	_, err = file.Write(data)
+	if err == nil {
+		err = file.Chmod(0644)
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(file.Name())
+		return err
+	}
+
+	err = os.Rename(file.Name(), path)
+	if err != nil {
+		os.Remove(file.Name())
+	}
+	return err
+}
+
+// Restore reads a Session saved to given path back, switching to its
+// backend, which must be registered. The Session is left untouched on error.
+func (s *Session) Restore(path string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	f := &file{}
+	err = json.Unmarshal(data, f)
+	if err != nil {
+		return fmt.Errorf("invalid session file %s: %w", path, err)
+	}
+
+	results := make([]*backends.Manga, 0, len(f.Results))
+	for _, saved := range f.Results {
+		manga, err := restoreManga(saved)
+		if err != nil {
+			return err
+		}
+		results = append(results, manga)
+	}
+
+	var series []*backends.Series
+	for _, saved := range f.Series {
+		restored := &backends.Series{Name: saved.Name, Author: saved.Author}
+		for _, source := range saved.Sources {
+			if source.Manga == nil {
+				return fmt.Errorf("invalid session file %s: series %s source has no manga", path, saved.Name)
+			}
+			manga, err := restoreManga(source.Manga)
+			if err != nil {
+				return err
+			}
+			restored.Sources = append(restored.Sources, &backends.Source{Backend: source.Backend, Manga: manga})
+		}
+		if len(restored.Sources) == 0 {
+			return fmt.Errorf("invalid session file %s: series %s has no source", path, saved.Name)
+		}
+		series = append(series, restored)
+	}
+
+	var manga *backends.Manga
+	if f.Manga != nil {
+		manga, err = restoreManga(f.Manga)
+		if err != nil {
+			return err
+		}
+	}
+
+	var chapter *backends.Chapter
+	if f.Chapter != nil {
+		u, err := url.Parse(f.Chapter.URL)
+		if err != nil {
+			return err
+		}
+		chapter = &backends.Chapter{Name: f.Chapter.Name, URL: u}
+	}
+
+	d := s.downloader
+	if d == nil || d.Slug != f.Backend {
+		d, err = s.Use(f.Backend)
+		if err != nil {
+			return err
+		}
+	}
+
+	s.SetDownloader(d)
+	if series != nil {
+		s.SetSeries(series)
+	} else {
+		s.SetResults(results)
+	}
+	s.SetManga(manga, chapter)
+	return nil
+}
+
+func saveManga(manga *backends.Manga) *savedManga {
+	return &savedManga{
+		ID:     manga.ID,
+		Name:   manga.Name,
+		Slug:   manga.Slug,
+		Author: manga.Author,
+		Genre:  manga.Genre,
+		URL:    urlString(manga.URL),
+	}
+}
+
+func restoreManga(saved *savedManga) (*backends.Manga, error) {
+	u, err := url.Parse(saved.URL)
+	if err != nil {
+		return nil, err
+	}
+	return &backends.Manga{
+		ID:     saved.ID,
+		Name:   saved.Name,
+		Slug:   saved.Slug,
+		Author: saved.Author,
+		Genre:  saved.Genre,
+		URL:    u,
+	}, nil
+}
+
+func urlString(u *url.URL) string {
+	if u == nil {
+		return ""
+	}
+	return u.String()
+}
@@ -0,0 +1,130 @@
+package session_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/toxinu/katago/backends"
+	"github.com/toxinu/katago/backends/backendtest"
+	"github.com/toxinu/katago/client"
+	"github.com/toxinu/katago/cmd/cli/session"
+	"github.com/toxinu/katago/downloader"
+)
+
+func TestSaveRestoreSeries(t *testing.T) {
+	dir, err := ioutil.TempDir("", "katago-session-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "session.json")
+
+	memory := backendtest.NewMemory(nil)
+	d := downloader.New(memory, client.NewClient())
+	d.Slug = "memory"
+
+	first := memory.AddManga("Berserk", 1, 1)
+	second := memory.AddManga("Vagabond", 1, 1)
+	series := []*backends.Series{
+		{Name: "Berserk", Author: "Miura Kentarou", Sources: []*backends.Source{
+			{Backend: "memory", Manga: first},
+			{Backend: "mangadex", Manga: &backends.Manga{Name: "Berserk", URL: first.URL}},
+		}},
+		{Name: "Vagabond", Sources: []*backends.Source{{Backend: "memory", Manga: second}}},
+	}
+
+	s := session.New(ioutil.Discard, session.Downloaders{"memory": d}, d)
+	s.SetSeries(series)
+	s.SetManga(first, nil)
+	err = s.Save(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	restored := session.New(ioutil.Discard, session.Downloaders{"memory": d}, d)
+	err = restored.Restore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(restored.Series(), series) {
+		t.Errorf("got series %+v, want %+v", restored.Series(), series)
+	}
+	if !reflect.DeepEqual(restored.Results(), s.Results()) {
+		t.Errorf("got results %+v, want %+v", restored.Results(), s.Results())
+	}
+	if manga, err := restored.Manga(); err != nil || !reflect.DeepEqual(manga, first) {
+		t.Errorf("got manga %+v, want %+v", manga, first)
+	}
+}
+
+func TestClear(t *testing.T) {
+	memory := backendtest.NewMemory(nil)
+	manga := memory.AddManga("Berserk", 1, 1)
+	d := downloader.New(memory, client.NewClient())
+
+	s := session.New(ioutil.Discard, session.Downloaders{"memory": d}, d)
+	s.SetResults([]*backends.Manga{manga})
+	s.SetManga(manga, nil)
+	s.SetChapters(manga, []*backends.Chapter{{Name: "Berserk 1"}})
+	s.Clear()
+
+	if _, err := s.Manga(); err != session.ErrNoManga {
+		t.Errorf("got %v, want ErrNoManga", err)
+	}
+	if len(s.Results()) > 0 || len(s.Chapters()) > 0 {
+		t.Error("results or chapters kept after Clear")
+	}
+	if !s.Cleared() {
+		t.Error("cleared session not reported")
+	}
+
+	s.SetManga(manga, nil)
+	if s.Cleared() {
+		t.Error("session reported cleared after a selection")
+	}
+}
+
+func TestSaveReplacesSession(t *testing.T) {
+	dir, err := ioutil.TempDir("", "katago-session-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "session.json")
+	err = ioutil.WriteFile(path, []byte("{\"backend\": \"memory\", \"results\": ["), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	memory := backendtest.NewMemory(nil)
+	manga := memory.AddManga("Berserk", 1, 1)
+	d := downloader.New(memory, client.NewClient())
+	d.Slug = "memory"
+
+	s := session.New(ioutil.Discard, session.Downloaders{"memory": d}, d)
+	s.SetResults([]*backends.Manga{manga})
+	err = s.Save(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	restored := session.New(ioutil.Discard, session.Downloaders{"memory": d}, d)
+	err = restored.Restore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(restored.Results(), s.Results()) {
+		t.Errorf("got results %+v, want %+v", restored.Results(), s.Results())
+	}
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 || files[0].Name() != "session.json" || files[0].Mode().Perm() != 0644 {
+		t.Errorf("got files %v, want session.json alone", files)
+	}
+}
@@ -0,0 +1,53 @@
+package session
+
+import (
+	"fmt"
+
+	"github.com/toxinu/katago/backends"
+	"github.com/toxinu/katago/downloader"
+)
+
+// Registry is where a Session finds backends to select
+type Registry interface {
+	// Backends returns the backends by slug
+	Backends() map[string]backends.Backend
+	// Use returns a Downloader for the backend of given slug
+	Use(slug string) (*downloader.Downloader, error)
+}
+
+// Registered is the Registry of backends registered by
+// downloader.NewDownloader
+var Registered Registry = registered{}
+
+type registered struct{}
+
+// Backends implements Registry interface
+func (registered) Backends() map[string]backends.Backend {
+	return backends.Backends
+}
+
+// Use implements Registry interface
+func (registered) Use(slug string) (*downloader.Downloader, error) {
+	return downloader.Use(slug)
+}
+
+// Downloaders is a Registry of given Downloaders, by backend slug
+type Downloaders map[string]*downloader.Downloader
+
+// Backends implements Registry interface
+func (r Downloaders) Backends() map[string]backends.Backend {
+	result := make(map[string]backends.Backend, len(r))
+	for slug, d := range r {
+		result[slug] = d.Backend
+	}
+	return result
+}
+
+// Use implements Registry interface
+func (r Downloaders) Use(slug string) (*downloader.Downloader, error) {
+	d, ok := r[slug]
+	if !ok {
+		return nil, fmt.Errorf("unknown backend \"%s\"", slug)
+	}
+	return d, nil
+}
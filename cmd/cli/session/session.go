@@ -0,0 +1,181 @@
+package session
+
+import (
+	"errors"
+	"io"
+	"sync"
+
+	"github.com/toxinu/katago/backends"
+	"github.com/toxinu/katago/downloader"
+)
+
+var (
+	// ErrNoBackend is returned when no backend is selected
+	ErrNoBackend = errors.New("you must select a backend before")
+	// ErrNoManga is returned when no manga is selected
+	ErrNoManga = errors.New("you must select a manga before")
+)
+
+// DefaultOutputDir is where chapters are downloaded unless set otherwise
+const DefaultOutputDir = "./mangas"
+
+// Session is the cli state actions read and change
+type Session struct {
+	out       io.Writer
+	registry  Registry
+	outputDir string
+
+	downloader *downloader.Downloader
+	results    []*backends.Manga
+	series     []*backends.Series
+	manga      *backends.Manga
+	chapter    *backends.Chapter
+	// cleared tells nothing was selected since the Session was cleared
+	cleared bool
+
+	// chapters are the last fetched chapters, of chaptersManga, they may
+	// be prefetched in the background
+	mutex         sync.Mutex
+	chaptersManga *backends.Manga
+	chapters      []*backends.Chapter
+}
+
+// New returns a Session printing to given writer, selecting backends of
+// given Registry and using given Downloader, which may be nil until a
+// backend is selected
+func New(out io.Writer, registry Registry, d *downloader.Downloader) *Session {
+	return &Session{out: out, registry: registry, outputDir: DefaultOutputDir, downloader: d}
+}
+
+// Out returns where actions print
+func (s *Session) Out() io.Writer {
+	return s.out
+}
+
+// Backends returns the backends which can be selected, by slug
+func (s *Session) Backends() map[string]backends.Backend {
+	return s.registry.Backends()
+}
+
+// Use returns a Downloader for the backend of given slug, without selecting
+// it
+func (s *Session) Use(slug string) (*downloader.Downloader, error) {
+	return s.registry.Use(slug)
+}
+
+// OutputDir returns where chapters are downloaded
+func (s *Session) OutputDir() string {
+	return s.outputDir
+}
+
+// SetOutputDir sets where chapters are downloaded
+func (s *Session) SetOutputDir(dir string) {
+	s.outputDir = dir
+}
+
+// Downloader returns the Downloader of the selected backend
+func (s *Session) Downloader() (*downloader.Downloader, error) {
+	if s.downloader == nil {
+		return nil, ErrNoBackend
+	}
+	return s.downloader, nil
+}
+
+// SetDownloader selects the backend of given Downloader
+func (s *Session) SetDownloader(d *downloader.Downloader) {
+	s.downloader, s.cleared = d, false
+}
+
+// Results returns the mangas listed by the last search
+func (s *Session) Results() []*backends.Manga {
+	return s.results
+}
+
+// Series returns the series results are the first source of, after a search
+// on every backend, nil otherwise
+func (s *Session) Series() []*backends.Series {
+	return s.series
+}
+
+// SetResults lists given mangas, from a single backend
+func (s *Session) SetResults(results []*backends.Manga) {
+	s.results, s.series, s.cleared = results, nil, false
+}
+
+// SetSeries lists given series, found on several backends, results are
+// their first source
+func (s *Session) SetSeries(series []*backends.Series) {
+	results := make([]*backends.Manga, 0, len(series))
+	for _, serie := range series {
+		results = append(results, serie.Sources[0].Manga)
+	}
+	s.results, s.series, s.cleared = results, series, false
+}
+
+// Manga returns the selected manga
+func (s *Session) Manga() (*backends.Manga, error) {
+	if s.manga == nil {
+		return nil, ErrNoManga
+	}
+	return s.manga, nil
+}
+
+// Chapter returns the selected chapter, nil when none is
+func (s *Session) Chapter() *backends.Chapter {
+	return s.chapter
+}
+
+// SetManga selects given manga and one of its chapters, chapter may be nil
+func (s *Session) SetManga(manga *backends.Manga, chapter *backends.Chapter) {
+	s.manga, s.chapter, s.cleared = manga, chapter, false
+}
+
+// Clear forgets results and the selected manga, keeping the selected
+// backend
+func (s *Session) Clear() {
+	s.results, s.series, s.manga, s.chapter = nil, nil, nil, nil
+	s.SetChapters(nil, nil)
+	s.cleared = true
+}
+
+// Cleared tells whether nothing was selected since Clear, there is nothing
+// worth saving then
+func (s *Session) Cleared() bool {
+	return s.cleared
+}
+
+// Chapters returns the selected manga chapters when they were fetched, nil
+// otherwise
+func (s *Session) Chapters() []*backends.Chapter {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.manga == nil || s.chaptersManga != s.manga {
+		return nil
+	}
+	return s.chapters
+}
+
+// SetChapters records fetched chapters of given manga
+func (s *Session) SetChapters(manga *backends.Manga, chapters []*backends.Chapter) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.chaptersManga, s.chapters = manga, chapters
+}
+
+// PrefetchChapters fetches the selected manga chapters in the background,
+// for Chapters to return them once done
+func (s *Session) PrefetchChapters() {
+	manga, d := s.manga, s.downloader
+	if manga == nil || d == nil {
+		return
+	}
+
+	go func() {
+		chapters, err := d.Backend.Chapters(manga)
+		if err == nil {
+			s.SetChapters(manga, chapters)
+		}
+	}()
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/toxinu/katago/backends/backendtest"
+)
+
+func main() {
+	backend := flag.String("backend", "mangafox", "backend to record")
+	term := flag.String("term", "", "search term")
+	output := flag.String("output", "", "cassette path (default backends/testdata/<backend>.json)")
+	flag.Parse()
+
+	if len(*term) == 0 {
+		fmt.Fprintln(os.Stderr, "Error: search term needed")
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if len(*output) == 0 {
+		*output = fmt.Sprintf("backends/testdata/%s.json", *backend)
+	}
+
+	walk, err := backendtest.Record(*backend, *term, *output)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Recorded %d results, %d chapters, %d pages and image %s in %s\n",
+		len(walk.Results), len(walk.Chapters), len(walk.Pages), walk.ImageURL, *output)
+}
@@ -0,0 +1,219 @@
+package config
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Config represents katago configuration
+type Config struct {
+	Backends  map[string]*Backend `json:"backends"`
+	Retry     *Retry              `json:"retry"`
+	Cache     *Cache              `json:"cache"`
+	Transport *Transport          `json:"transport"`
+	// Definitions is the directory of site definition files
+	Definitions string `json:"definitions"`
+	// Plugins is the directory of backend plugin executables
+	Plugins string `json:"plugins"`
+	// Scripts is the directory of backend Starlark scripts
+	Scripts string `json:"scripts"`
+	// Library is the directory of mangas read by the local backend
+	Library string `json:"library"`
+	// Cookies is the directory of backend cookie jars
+	Cookies string `json:"cookies"`
+	// Fallbacks are the backends failing chapters are looked up on, in
+	// order, none when missing
+	Fallbacks []string `json:"fallbacks"`
+}
+
+// Transport represents how sites are reached, zero values keep defaults
+type Transport struct {
+	// Proxy is an http, https or socks5 URL, or "direct"
+	Proxy                     string   `json:"proxy"`
+	ConnectTimeout            Duration `json:"connect_timeout"`
+	ReadTimeout               Duration `json:"read_timeout"`
+	MaxIdleConnections        int      `json:"max_idle_connections"`
+	MaxIdleConnectionsPerHost int      `json:"max_idle_connections_per_host"`
+	IdleConnectionTimeout     Duration `json:"idle_connection_timeout"`
+	// CAFile is a PEM bundle of trusted certificate authorities
+	CAFile string `json:"ca_file"`
+}
+
+// Cache represents HTTP responses disk cache configuration
+type Cache struct {
+	Enabled bool   `json:"enabled"`
+	Dir     string `json:"dir"`
+	// MaxSize is the cache size in bytes, zero means unlimited
+	MaxSize int64 `json:"max_size"`
+	// TTLs overrides how long responses are fresh per request type
+	TTLs map[string]Duration `json:"ttls"`
+}
+
+// Retry represents failed requests retry policy, zero values keep defaults
+type Retry struct {
+	MaxAttempts int      `json:"max_attempts"`
+	BaseDelay   Duration `json:"base_delay"`
+	MaxDelay    Duration `json:"max_delay"`
+	Multiplier  float64  `json:"multiplier"`
+	Jitter      float64  `json:"jitter"`
+}
+
+// Duration is a time.Duration read from a string like "500ms"
+type Duration time.Duration
+
+// UnmarshalJSON implements json.Unmarshaler interface
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var value string
+	err := json.Unmarshal(data, &value)
+	if err != nil {
+		return err
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return err
+	}
+
+	*d = Duration(duration)
+	return nil
+}
+
+// Backend represents a backend configuration
+type Backend struct {
+	// RateLimit is the number of requests per second allowed per host
+	RateLimit float64 `json:"rate_limit"`
+	// RateBurst is the number of requests allowed to be sent at once
+	RateBurst int `json:"rate_burst"`
+	// MaxConnections is the number of concurrent connections allowed per host
+	MaxConnections int `json:"max_connections"`
+	// Proxy overrides global proxy for this backend
+	Proxy string `json:"proxy"`
+	// CookiesFile is a Netscape cookies.txt file imported in backend cookie jar
+	CookiesFile string `json:"cookies_file"`
+	// Username and Password are used by backends requiring a login
+	Username string `json:"username"`
+	Password string `json:"password"`
+	// Languages are chapter languages by order of preference, for backends
+	// offering translations
+	Languages []string `json:"languages"`
+	// DataSaver downloads compressed images when the backend offers them
+	DataSaver bool `json:"data_saver"`
+}
+
+// New returns an empty Config
+func New() *Config {
+	return &Config{Backends: map[string]*Backend{}}
+}
+
+// Path returns default configuration file path
+func Path() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		dir = "."
+	}
+	return filepath.Join(dir, "katago", "config.json")
+}
+
+// HistoryPath returns the cli commands history path
+func HistoryPath() string {
+	return filepath.Join(filepath.Dir(Path()), "history")
+}
+
+// SessionPath returns the cli session path, restored on launch
+func SessionPath() string {
+	return filepath.Join(filepath.Dir(Path()), "session.json")
+}
+
+// CachePath returns default cache directory path
+func CachePath() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = "."
+	}
+	return filepath.Join(dir, "katago")
+}
+
+// Load reads configuration file, a missing file returns an empty Config
+func Load(path string) (*Config, error) {
+	cfg := New()
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(data, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.Backends == nil {
+		cfg.Backends = map[string]*Backend{}
+	}
+	if cfg.Cache != nil && len(cfg.Cache.Dir) == 0 {
+		cfg.Cache.Dir = CachePath()
+	}
+
+	return cfg, nil
+}
+
+// DefinitionsDir returns site definitions directory
+func (c *Config) DefinitionsDir() string {
+	if len(c.Definitions) > 0 {
+		return c.Definitions
+	}
+	return filepath.Join(filepath.Dir(Path()), "definitions")
+}
+
+// PluginsDir returns backend plugins directory
+func (c *Config) PluginsDir() string {
+	if len(c.Plugins) > 0 {
+		return c.Plugins
+	}
+	return filepath.Join(filepath.Dir(Path()), "plugins")
+}
+
+// ScriptsDir returns backend scripts directory
+func (c *Config) ScriptsDir() string {
+	if len(c.Scripts) > 0 {
+		return c.Scripts
+	}
+	return filepath.Join(filepath.Dir(Path()), "scripts")
+}
+
+// CookiesDir returns backend cookie jars directory
+func (c *Config) CookiesDir() string {
+	if len(c.Cookies) > 0 {
+		return c.Cookies
+	}
+	return filepath.Join(filepath.Dir(Path()), "cookies")
+}
+
+// CookieJarPath returns given backend cookie jar path
+func (c *Config) CookieJarPath(slug string) string {
+	return filepath.Join(c.CookiesDir(), slug+".json")
+}
+
+// LibraryDir returns the directory of mangas read by the local backend,
+// downloads directory by default
+func (c *Config) LibraryDir() string {
+	if len(c.Library) > 0 {
+		return c.Library
+	}
+	return "mangas"
+}
+
+// Backend returns given backend configuration
+func (c *Config) Backend(slug string) *Backend {
+	b, ok := c.Backends[slug]
+	if !ok || b == nil {
+		return &Backend{}
+	}
+	return b
+}
@@ -1,17 +1,24 @@
 package downloader
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/url"
 	"os"
 	"path"
 	"path/filepath"
 	"regexp"
 	"strconv"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/toxinu/katago/backends"
 	"github.com/toxinu/katago/client"
+	"github.com/toxinu/katago/config"
 )
 
 var (
@@ -20,31 +27,167 @@ var (
 
 // Downloader downloads manga
 type Downloader struct {
-	Backend         backends.Backend
-	Client          *client.Client
+	Backend backends.Backend
+	Client  *client.Client
+	// Slug is the slug Backend is registered with
+	Slug string
+	// Fallbacks are tried in order when a chapter fails on Backend
+	Fallbacks       []*Fallback
 	ParallelChapter int
 	ParallelPage    int
+
+	mutex   sync.Mutex
+	mirrors map[string]*mirror
 }
 
 // NewDownloader returns a Downloader
 func NewDownloader(backendName string) (*Downloader, error) {
-	c := &client.Client{Retry: 10}
+	cfg, err := config.Load(config.Path())
+	if err != nil {
+		return nil, err
+	}
+
+	c := client.NewClient()
+	c.Retry = retryPolicy(cfg, c.Retry)
+	c.Cache = cache(cfg)
+	c, err = c.WithTransport(transportOptions(cfg, c.Transport))
+	if err != nil {
+		return nil, err
+	}
+
+	err = backends.Initialize(c, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := backends.Get(backendName)
+	if err != nil {
+		return nil, err
+	}
+
+	err = backends.Login(b, cfg, backendName)
+	if err != nil {
+		return nil, err
+	}
+
+	d := New(b, backends.Clients[backendName])
+	d.Slug = backendName
+	d.Fallbacks = fallbacks(cfg, backendName)
+	return d, nil
+}
+
+// Use returns a Downloader for a backend registered by a previous
+// NewDownloader call, without initializing backends again
+func Use(backendName string) (*Downloader, error) {
+	cfg, err := config.Load(config.Path())
+	if err != nil {
+		return nil, err
+	}
 
-	backends.Initialize(c)
 	b, err := backends.Get(backendName)
 	if err != nil {
 		return nil, err
 	}
 
+	err = backends.Login(b, cfg, backendName)
+	if err != nil {
+		return nil, err
+	}
+
+	d := New(b, backends.Clients[backendName])
+	d.Slug = backendName
+	d.Fallbacks = fallbacks(cfg, backendName)
+	return d, nil
+}
+
+// New returns a Downloader using given backend and client, whatever the
+// registered backends are
+func New(b backends.Backend, c *client.Client) *Downloader {
 	return &Downloader{
 		Backend:         b,
 		Client:          c,
 		ParallelChapter: 5,
 		ParallelPage:    5,
-	}, nil
+	}
+}
+
+func retryPolicy(cfg *config.Config, policy client.RetryPolicy) client.RetryPolicy {
+	if cfg.Retry == nil {
+		return policy
+	}
+	if cfg.Retry.MaxAttempts > 0 {
+		policy.MaxAttempts = cfg.Retry.MaxAttempts
+	}
+	if cfg.Retry.BaseDelay > 0 {
+		policy.BaseDelay = time.Duration(cfg.Retry.BaseDelay)
+	}
+	if cfg.Retry.MaxDelay > 0 {
+		policy.MaxDelay = time.Duration(cfg.Retry.MaxDelay)
+	}
+	if cfg.Retry.Multiplier > 0 {
+		policy.Multiplier = cfg.Retry.Multiplier
+	}
+	if cfg.Retry.Jitter > 0 {
+		policy.Jitter = cfg.Retry.Jitter
+	}
+	return policy
+}
+
+func transportOptions(cfg *config.Config, options client.TransportOptions) client.TransportOptions {
+	if cfg.Transport == nil {
+		return options
+	}
+	if len(cfg.Transport.Proxy) > 0 {
+		options.Proxy = cfg.Transport.Proxy
+	}
+	if cfg.Transport.ConnectTimeout > 0 {
+		options.ConnectTimeout = time.Duration(cfg.Transport.ConnectTimeout)
+	}
+	if cfg.Transport.ReadTimeout > 0 {
+		options.ReadTimeout = time.Duration(cfg.Transport.ReadTimeout)
+	}
+	if cfg.Transport.MaxIdleConnections > 0 {
+		options.MaxIdleConns = cfg.Transport.MaxIdleConnections
+	}
+	if cfg.Transport.MaxIdleConnectionsPerHost > 0 {
+		options.MaxIdleConnsPerHost = cfg.Transport.MaxIdleConnectionsPerHost
+	}
+	if cfg.Transport.IdleConnectionTimeout > 0 {
+		options.IdleConnTimeout = time.Duration(cfg.Transport.IdleConnectionTimeout)
+	}
+	if len(cfg.Transport.CAFile) > 0 {
+		options.CAFile = cfg.Transport.CAFile
+	}
+	return options
+}
+
+func cache(cfg *config.Config) *client.Cache {
+	if cfg.Cache == nil || !cfg.Cache.Enabled {
+		return nil
+	}
+
+	c := client.NewCache(cfg.Cache.Dir, cfg.Cache.MaxSize)
+	for requestType, ttl := range cfg.Cache.TTLs {
+		c.TTLs[client.RequestType(requestType)] = time.Duration(ttl)
+	}
+	return c
+}
+
+// WithContext returns a copy of Downloader whose requests, fallback ones
+// included, are bound to given Context
+func (d *Downloader) WithContext(ctx context.Context) *Downloader {
+	return &Downloader{
+		Backend:         d.Backend,
+		Client:          d.Client.WithContext(ctx),
+		Slug:            d.Slug,
+		Fallbacks:       d.Fallbacks,
+		ParallelChapter: d.ParallelChapter,
+		ParallelPage:    d.ParallelPage,
+	}
 }
 
-// Download retrieves a manga's chapters
+// Download retrieves a manga's chapters, chapters left once Downloader
+// Client context is done fail with client.ErrCancelled
 func (d *Downloader) Download(manga *backends.Manga, chapters []*backends.Chapter, output string, results chan<- error) {
 	var waitGroup sync.WaitGroup
 
@@ -68,6 +211,10 @@ func (d *Downloader) Download(manga *backends.Manga, chapters []*backends.Chapte
 	for i := 0; i < d.ParallelChapter; i++ {
 		go func() {
 			for mangaChapterTask := range tasks {
+				if d.Client.Context().Err() != nil {
+					results <- fmt.Errorf("%s: %w", mangaChapterTask.chapter.Name, client.ErrCancelled)
+					continue
+				}
 				results <- d.DownloadChapter(mangaChapterTask.manga, mangaChapterTask.chapter, output)
 			}
 			waitGroup.Done()
@@ -80,13 +227,66 @@ func (d *Downloader) Download(manga *backends.Manga, chapters []*backends.Chapte
 	}()
 }
 
-// DownloadChapter retrieves a manga's chapter
+func (d *Downloader) slug() string {
+	if len(d.Slug) > 0 {
+		return d.Slug
+	}
+	return d.Backend.Name()
+}
+
+// ChapterDir returns the folder given chapter is downloaded to in output,
+// manga and chapter names are made single folder names so they cannot nest
+// folders nor leave output
+func ChapterDir(output string, manga *backends.Manga, chapter *backends.Chapter) string {
+	return path.Join(output, FolderName(manga.Name), FolderName(chapter.Name))
+}
+
+// FolderName returns given name with its path separators replaced, empty,
+// "." and ".." names are replaced by underscores
+func FolderName(name string) string {
+	name = strings.NewReplacer("/", "-", "\\", "-", "\x00", "").Replace(strings.TrimSpace(name))
+	switch name {
+	case "":
+		return "_"
+	case ".", "..":
+		return strings.Repeat("_", len(name))
+	}
+	return name
+}
+
+// DownloadChapter retrieves a manga's chapter, a chapter failing on
+// Downloader backend is looked up on its fallbacks
 func (d *Downloader) DownloadChapter(manga *backends.Manga, chapter *backends.Chapter, output string) error {
+	err := d.downloadChapter(manga, chapter, output, &ChapterSource{
+		Backend:    d.slug(),
+		MangaURL:   manga.URL.String(),
+		ChapterURL: chapter.URL.String(),
+	})
+	if err == nil || !backendFailure(err) || len(d.Fallbacks) == 0 {
+		return err
+	}
+	return d.fallback(manga, chapter, output, err)
+}
+
+// downloadChapter retrieves a manga's chapter from Downloader backend and
+// records given source in chapter folder
+func (d *Downloader) downloadChapter(manga *backends.Manga, chapter *backends.Chapter, output string, source *ChapterSource) error {
 	var (
 		waitGroup sync.WaitGroup
 	)
 
-	output = path.Join(output, manga.Name, chapter.Name)
+	output = ChapterDir(output, manga, chapter)
+	_, statErr := os.Stat(output)
+	existed := statErr == nil
+
+	// A chapter downloaded again is not complete until its source is
+	// recorded anew
+	if existed {
+		err := os.Remove(path.Join(output, SourceFile))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
 
 	pages, err := d.Backend.Pages(chapter)
 	if err != nil {
@@ -114,7 +314,12 @@ func (d *Downloader) DownloadChapter(manga *backends.Manga, chapter *backends.Ch
 	for i := 0; i < d.ParallelPage; i++ {
 		go func() {
 			for chapterPageTask := range tasks {
-				result <- d.DownloadPage(chapterPageTask.page, chapterPageTask.index, output)
+				err := d.DownloadPage(chapterPageTask.page, chapterPageTask.index, output)
+				if err != nil {
+					removePage(output, chapterPageTask.index)
+					err = &PageError{Chapter: chapter, Index: chapterPageTask.index, Err: err}
+				}
+				result <- err
 			}
 			waitGroup.Done()
 		}()
@@ -125,45 +330,98 @@ func (d *Downloader) DownloadChapter(manga *backends.Manga, chapter *backends.Ch
 		close(result)
 	}()
 
+	// result is drained even after a failure, returning early would leave
+	// page workers blocked on sending their result, and the task feeder on
+	// its next page, for the lifetime of the process
+	var firstErr error
 	for err := range result {
-		if err != nil {
-			return err
+		if err != nil && firstErr == nil {
+			firstErr = err
 		}
 	}
 
-	return nil
+	// Pages of a failed chapter are not kept, unless the chapter folder was
+	// already there
+	if firstErr != nil {
+		if !existed {
+			os.RemoveAll(output)
+		}
+		return firstErr
+	}
+
+	return writeChapterSource(output, source)
 }
 
-// DownloadPage retrieve a Manga Page
-func (d *Downloader) DownloadPage(page *backends.Page, index int, output string) error {
+// removePage removes the file of the page at given index, a page failing
+// in an existing chapter folder must not leave a previous download of it
+func removePage(output string, index int) {
+	files, err := ioutil.ReadDir(output)
+	if err != nil {
+		return
+	}
+
+	name := strconv.Itoa(index)
+	for _, file := range files {
+		if file.Name() == name || strings.TrimSuffix(file.Name(), filepath.Ext(file.Name())) == name {
+			os.Remove(path.Join(output, file.Name()))
+		}
+	}
+}
+
+// getPageImage requests page image, image URLs may expire so a missing or
+// forbidden image is looked up once more before giving up
+func (d *Downloader) getPageImage(page *backends.Page) (*http.Response, error) {
 	var (
-		err  error
-		resp *http.Response
+		err      error
+		imageURL *url.URL
+		resp     *http.Response
 	)
 
-	pagePath := path.Join(output, strconv.Itoa(index))
-
-	for i := 0; i < 10; i++ {
-		imageURL, err := d.Backend.PageImageURL(page)
+	for i := 0; i < 2; i++ {
+		imageURL, err = d.Backend.PageImageURL(page)
 		if err != nil {
-			continue
+			return nil, err
 		}
 
-		// resp, err := d.Client.Get(imageURL, make([]int, 1))
-		resp, err = d.Client.Get(imageURL, []int{200})
-		if err != nil {
-			continue
-		} else {
-			break
+		resp, err = d.Client.For(client.RequestImage).Get(imageURL, []int{200})
+		if err == nil {
+			return resp, nil
+		}
+
+		var statusError *client.StatusError
+		if !errors.As(err, &statusError) || (statusError.Code != http.StatusForbidden && !errors.Is(err, client.ErrNotFound)) {
+			return nil, err
+		}
+
+		if d.Client.Cache != nil {
+			d.Client.Cache.Delete(page.URL)
+		}
+		if expirer, ok := d.Backend.(backends.Expirer); ok {
+			expirer.Expire(page)
 		}
 	}
 
+	return nil, err
+}
+
+// DownloadPage retrieve a Manga Page
+func (d *Downloader) DownloadPage(page *backends.Page, index int, output string) error {
+	pagePath := path.Join(output, strconv.Itoa(index))
+
+	resp, err := d.getPageImage(page)
 	if err != nil {
 		return err
 	}
 
 	defer resp.Body.Close()
 
+	// Sites answer hotlinked or expired images with a 200 HTML page, saved
+	// as is it would pass for a downloaded page
+	contentType := resp.Header.Get("content-type")
+	if strings.HasPrefix(contentType, "text/") {
+		return fmt.Errorf("unexpected content type '%s' for page image %s", contentType, resp.Request.URL)
+	}
+
 	data, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
 		return err
@@ -171,7 +429,6 @@ func (d *Downloader) DownloadPage(page *backends.Page, index int, output string)
 
 	var extension string
 	if len(extension) == 0 {
-		contentType := resp.Header.Get("content-type")
 		if len(contentType) > 0 {
 			matches := regexpImageContentType.FindStringSubmatch(contentType)
 			if matches != nil {
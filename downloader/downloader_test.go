@@ -0,0 +1,504 @@
+package downloader_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/toxinu/katago/backends"
+	"github.com/toxinu/katago/backends/backendtest"
+	"github.com/toxinu/katago/client"
+	"github.com/toxinu/katago/downloader"
+)
+
+// newDownloader returns a Downloader of a Memory backend having one manga,
+// and the directory it downloads to
+func newDownloader(t *testing.T, chapters int, pages int) (*downloader.Downloader, *backendtest.Memory, *backends.Manga, string) {
+	images := backendtest.NewImageServer()
+	t.Cleanup(images.Close)
+
+	memory := backendtest.NewMemory(images)
+	manga := memory.AddManga("Berserk", chapters, pages)
+
+	output, err := ioutil.TempDir("", "katago-downloads-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(output) })
+
+	d := downloader.New(memory, testClient())
+	d.Slug = "memory"
+	return d, memory, manga, output
+}
+
+// testClient returns a Client sending requests at once, without retrying
+func testClient() *client.Client {
+	c := client.NewClient()
+	c.Limiter = nil
+	c.Retry.MaxAttempts = 1
+	return c
+}
+
+// retryClient returns a Client retrying requests without waiting long,
+// sending them through its own Limiter
+func retryClient() *client.Client {
+	c := client.NewClient()
+	c.Limiter = client.NewLimiter()
+	c.Retry = client.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 10 * time.Millisecond}
+	return c
+}
+
+func chapters(t *testing.T, b backends.Backend, manga *backends.Manga) []*backends.Chapter {
+	chapters, err := b.Chapters(manga)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return chapters
+}
+
+func pages(t *testing.T, b backends.Backend, chapter *backends.Chapter) []*backends.Page {
+	pages, err := b.Pages(chapter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pages
+}
+
+func TestDownloadPageLooksUpExpiredImagesAgain(t *testing.T) {
+	for _, status := range []int{http.StatusForbidden, http.StatusNotFound} {
+		d, memory, manga, output := newDownloader(t, 1, 2)
+		chapter := chapters(t, memory, manga)[0]
+		page := pages(t, memory, chapter)[1]
+		memory.Images.SetBehavior(memory.ImagePath(page), backendtest.Behavior{Failures: 1, FailureStatus: status})
+
+		err := d.DownloadChapter(manga, chapter, output)
+		if err != nil {
+			t.Fatalf("%d: %s", status, err)
+		}
+		if requests := memory.Images.Requests(memory.ImagePath(page)); requests != 2 {
+			t.Errorf("%d: image requested %d times, want 2", status, requests)
+		}
+		// Each page image URL is looked up once, and once more for the
+		// expired one
+		if calls := memory.Calls("PageImageURL"); calls != 3 {
+			t.Errorf("%d: image URLs looked up %d times, want 3", status, calls)
+		}
+		if _, err := os.Stat(filepath.Join(output, manga.Name, chapter.Name, "2.png")); err != nil {
+			t.Errorf("%d: page not written: %s", status, err)
+		}
+	}
+}
+
+func TestDownloadPageError(t *testing.T) {
+	d, memory, manga, output := newDownloader(t, 1, 3)
+	chapter := chapters(t, memory, manga)[0]
+	page := pages(t, memory, chapter)[2]
+	memory.Images.SetBehavior(memory.ImagePath(page), backendtest.Behavior{Failures: 2, FailureStatus: http.StatusForbidden})
+
+	err := d.DownloadChapter(manga, chapter, output)
+
+	var pageError *downloader.PageError
+	if !errors.As(err, &pageError) {
+		t.Fatalf("got %v, want a PageError", err)
+	}
+	if pageError.Chapter != chapter || pageError.Index != 3 {
+		t.Errorf("got error for %s page %d, want %s page 3", pageError.Chapter.Name, pageError.Index, chapter.Name)
+	}
+	var statusError *client.StatusError
+	if !errors.As(err, &statusError) || statusError.Code != http.StatusForbidden {
+		t.Errorf("got %v, want a 403 StatusError", err)
+	}
+	if _, err := os.Stat(filepath.Join(output, manga.Name, chapter.Name, downloader.SourceFile)); !os.IsNotExist(err) {
+		t.Error("failed chapter has a source file")
+	}
+}
+
+func TestDownloadPageRejectsTextContent(t *testing.T) {
+	d, memory, manga, output := newDownloader(t, 1, 1)
+	chapter := chapters(t, memory, manga)[0]
+	page := pages(t, memory, chapter)[0]
+	memory.Images.SetBehavior(memory.ImagePath(page), backendtest.Behavior{ContentType: "text/html"})
+
+	err := d.DownloadChapter(manga, chapter, output)
+	if err == nil {
+		t.Fatal("an HTML page was saved as an image")
+	}
+	if files, _ := filepath.Glob(filepath.Join(output, manga.Name, chapter.Name, "1*")); len(files) > 0 {
+		t.Errorf("HTML page written to %v", files)
+	}
+}
+
+func TestDownloadCancelled(t *testing.T) {
+	d, memory, manga, output := newDownloader(t, 1, 10)
+	memory.Images.Default = backendtest.Behavior{Delay: 10 * time.Second}
+
+	fallback := backendtest.NewMemory(memory.Images)
+	fallback.AddManga("Berserk", 1, 1)
+	d.Fallbacks = []*downloader.Fallback{{Slug: "fallback", Backend: fallback, Client: testClient()}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	d.Client = d.Client.WithContext(ctx)
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	done := make(chan error)
+	go func() {
+		done <- d.DownloadChapter(manga, chapters(t, memory, manga)[0], output)
+	}()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, client.ErrCancelled) {
+			t.Errorf("got %v, want ErrCancelled", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("cancelled download did not return")
+	}
+	if calls := fallback.Calls("Search"); calls > 0 {
+		t.Error("cancelled chapter was looked up on fallback")
+	}
+}
+
+func TestDownloadFallback(t *testing.T) {
+	d, memory, manga, output := newDownloader(t, 2, 2)
+	chapter := chapters(t, memory, manga)[1]
+	cause := &client.StatusError{Code: http.StatusNotFound, URL: chapter.URL}
+	memory.Errors[chapter.URL.String()] = cause
+
+	fallback := backendtest.NewMemory(memory.Images)
+	fallbackManga := fallback.AddManga("Berserk", 3, 1)
+	d.Fallbacks = []*downloader.Fallback{{Slug: "fallback", Backend: fallback, Client: testClient()}}
+
+	err := d.DownloadChapter(manga, chapter, output)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	chapterOutput := filepath.Join(output, manga.Name, chapter.Name)
+	data, err := ioutil.ReadFile(filepath.Join(chapterOutput, downloader.SourceFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	source := &downloader.ChapterSource{}
+	err = json.Unmarshal(data, source)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := downloader.ChapterSource{
+		Backend:      "fallback",
+		MangaURL:     fallbackManga.URL.String(),
+		ChapterURL:   chapters(t, fallback, fallbackManga)[1].URL.String(),
+		FallbackFrom: "memory",
+		Error:        cause.Error(),
+	}
+	if *source != want {
+		t.Errorf("got source %+v, want %+v", *source, want)
+	}
+	if _, err := os.Stat(filepath.Join(chapterOutput, "1.png")); err != nil {
+		t.Errorf("fallback page not written: %s", err)
+	}
+	if _, err := os.Stat(chapterOutput + ".fallback"); !os.IsNotExist(err) {
+		t.Error("fallback temporary folder left behind")
+	}
+}
+
+func TestChapterDirStaysInOutput(t *testing.T) {
+	tests := []struct {
+		manga   string
+		chapter string
+		want    string
+	}{
+		{"Berserk", "Berserk 1", "mangas/Berserk/Berserk 1"},
+		{"../..", "Chapter 1", "mangas/..-../Chapter 1"},
+		{"..", "..", "mangas/__/__"},
+		{"Fate/Zero", "1/2", "mangas/Fate-Zero/1-2"},
+		{`C:\Windows`, " ", `mangas/C:-Windows/_`},
+	}
+
+	for _, test := range tests {
+		got := downloader.ChapterDir("mangas", &backends.Manga{Name: test.manga}, &backends.Chapter{Name: test.chapter})
+		if got != test.want {
+			t.Errorf("ChapterDir(%q, %q) = %q, want %q", test.manga, test.chapter, got, test.want)
+		}
+	}
+}
+
+func TestDownloadDiskErrorDoesNotFallBack(t *testing.T) {
+	d, memory, manga, output := newDownloader(t, 1, 1)
+	// A file where the manga folder goes makes every write fail
+	err := ioutil.WriteFile(filepath.Join(output, manga.Name), nil, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fallback := backendtest.NewMemory(memory.Images)
+	fallback.AddManga("Berserk", 1, 1)
+	d.Fallbacks = []*downloader.Fallback{{Slug: "fallback", Backend: fallback, Client: testClient()}}
+
+	err = d.DownloadChapter(manga, chapters(t, memory, manga)[0], output)
+	var pathError *os.PathError
+	if !errors.As(err, &pathError) {
+		t.Errorf("got %v, want a PathError", err)
+	}
+	if calls := fallback.Calls("Search"); calls > 0 {
+		t.Error("chapter failing to be written was looked up on fallback")
+	}
+}
+
+func TestDownloadFallbackAmbiguousSeries(t *testing.T) {
+	d, memory, manga, output := newDownloader(t, 1, 1)
+	chapter := chapters(t, memory, manga)[0]
+	cause := &client.StatusError{Code: http.StatusNotFound, URL: chapter.URL}
+	memory.Errors[chapter.URL.String()] = cause
+
+	// Without authors, two series of the same title could be either one
+	fallback := backendtest.NewMemory(memory.Images)
+	fallback.AddManga("Berserk", 1, 1)
+	fallback.AddManga("Berserk!", 1, 1)
+	d.Fallbacks = []*downloader.Fallback{{Slug: "fallback", Backend: fallback, Client: testClient()}}
+
+	err := d.DownloadChapter(manga, chapter, output)
+	if err != cause {
+		t.Errorf("got %v, want the chapter error", err)
+	}
+	if calls := fallback.Calls("Chapters"); calls > 0 {
+		t.Error("ambiguous series was used as fallback")
+	}
+}
+
+func TestFailedChapterIsNotDownloaded(t *testing.T) {
+	d, memory, manga, output := newDownloader(t, 1, 3)
+	chapter := chapters(t, memory, manga)[0]
+	memory.Images.SetBehavior(memory.ImagePath(pages(t, memory, chapter)[2]), backendtest.Behavior{Failures: 2, FailureStatus: http.StatusForbidden})
+
+	err := d.DownloadChapter(manga, chapter, output)
+	if err == nil {
+		t.Fatal("chapter with a missing page downloaded")
+	}
+	if d.Downloaded(manga, chapter, output) {
+		t.Error("failed chapter taken for downloaded")
+	}
+}
+
+func TestInterruptedChapterIsNotDownloaded(t *testing.T) {
+	d, memory, manga, output := newDownloader(t, 1, 3)
+	chapter := chapters(t, memory, manga)[0]
+	chapterOutput := downloader.ChapterDir(output, manga, chapter)
+	err := os.MkdirAll(chapterOutput, 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for index, name := range []string{"1.png", "2.png", "3.png"} {
+		err = ioutil.WriteFile(filepath.Join(chapterOutput, name), nil, 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Without a source, a chapter is complete once it holds every page
+		if downloaded := d.Downloaded(manga, chapter, output); downloaded != (index == 2) {
+			t.Errorf("%d pages: got downloaded %v", index+1, downloaded)
+		}
+	}
+}
+
+func TestFailedRetryIsNotDownloaded(t *testing.T) {
+	d, memory, manga, output := newDownloader(t, 1, 3)
+	chapter := chapters(t, memory, manga)[0]
+	err := d.DownloadChapter(manga, chapter, output)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The image was already requested once by the first download
+	memory.Images.SetBehavior(memory.ImagePath(pages(t, memory, chapter)[2]), backendtest.Behavior{Failures: 3, FailureStatus: http.StatusNotFound})
+	err = d.DownloadChapter(manga, chapter, output)
+	if err == nil {
+		t.Fatal("chapter with a missing page downloaded")
+	}
+	if d.Downloaded(manga, chapter, output) {
+		t.Error("failed retry taken for downloaded")
+	}
+
+	chapterOutput := downloader.ChapterDir(output, manga, chapter)
+	if _, err := os.Stat(filepath.Join(chapterOutput, "1.png")); err != nil {
+		t.Errorf("page of existing folder not kept: %s", err)
+	}
+	if _, err := os.Stat(filepath.Join(chapterOutput, "3.png")); !os.IsNotExist(err) {
+		t.Errorf("got %v for failed page, want it removed", err)
+	}
+}
+
+func TestDownloadWithContextCancelsFallback(t *testing.T) {
+	d, memory, manga, output := newDownloader(t, 3, 1)
+	memory.Images.Default = backendtest.Behavior{Delay: 10 * time.Second}
+	list := chapters(t, memory, manga)
+	for _, chapter := range list {
+		memory.Errors[chapter.URL.String()] = &client.StatusError{Code: http.StatusNotFound, URL: chapter.URL}
+	}
+
+	fallback := backendtest.NewMemory(memory.Images)
+	fallback.AddManga("Berserk", 3, 1)
+	d.Fallbacks = []*downloader.Fallback{{Slug: "fallback", Backend: fallback, Client: testClient()}}
+	d.ParallelChapter = 1
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	results := make(chan error)
+	d.WithContext(ctx).Download(manga, list, output, results)
+
+	done := make(chan []error)
+	go func() {
+		var errs []error
+		for err := range results {
+			errs = append(errs, err)
+		}
+		done <- errs
+	}()
+
+	select {
+	case errs := <-done:
+		if len(errs) != len(list) {
+			t.Fatalf("got %d results, want %d", len(errs), len(list))
+		}
+		for _, err := range errs {
+			if !errors.Is(err, client.ErrCancelled) {
+				t.Errorf("got %v, want ErrCancelled", err)
+			}
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("fallback download was not cancelled")
+	}
+	if calls := fallback.Calls("Pages"); calls != 1 {
+		t.Errorf("fallback pages looked up for %d chapters, want 1 before cancellation", calls)
+	}
+}
+
+func TestDownloadRetriesServerErrors(t *testing.T) {
+	d, memory, manga, output := newDownloader(t, 1, 2)
+	d.Client = retryClient()
+	chapter := chapters(t, memory, manga)[0]
+	page := pages(t, memory, chapter)[0]
+	memory.Images.SetBehavior(memory.ImagePath(page), backendtest.Behavior{Failures: 2})
+
+	err := d.DownloadChapter(manga, chapter, output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if requests := memory.Images.Requests(memory.ImagePath(page)); requests != 3 {
+		t.Errorf("image requested %d times, want 3", requests)
+	}
+	if !d.Downloaded(manga, chapter, output) {
+		t.Error("chapter downloaded after retries not taken for downloaded")
+	}
+}
+
+func TestDownloadReportsTruncatedImages(t *testing.T) {
+	d, memory, manga, output := newDownloader(t, 1, 2)
+	d.Client = retryClient()
+	chapter := chapters(t, memory, manga)[0]
+	page := pages(t, memory, chapter)[1]
+	memory.Images.SetBehavior(memory.ImagePath(page), backendtest.Behavior{Truncate: true})
+
+	err := d.DownloadChapter(manga, chapter, output)
+
+	var pageError *downloader.PageError
+	if !errors.As(err, &pageError) || pageError.Index != 2 {
+		t.Fatalf("got %v, want a PageError of page 2", err)
+	}
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("got %v, want an unexpected EOF", err)
+	}
+	if d.Downloaded(manga, chapter, output) {
+		t.Error("chapter with a truncated page taken for downloaded")
+	}
+}
+
+func TestDownloadFollowsRedirects(t *testing.T) {
+	d, memory, manga, output := newDownloader(t, 1, 1)
+	d.Client = retryClient()
+	chapter := chapters(t, memory, manga)[0]
+	page := pages(t, memory, chapter)[0]
+	memory.Images.SetBehavior(memory.ImagePath(page), backendtest.Behavior{Redirect: true})
+
+	err := d.DownloadChapter(manga, chapter, output)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(output, manga.Name, chapter.Name, "1.png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, backendtest.Image(memory.ImagePath(page))) {
+		t.Error("redirected image not written")
+	}
+}
+
+func TestDownloadRespectsConnectionLimit(t *testing.T) {
+	d, memory, manga, output := newDownloader(t, 4, 3)
+	d.Client = retryClient()
+	d.Client.Limit = client.Limit{MaxConnections: 2}
+	d.ParallelChapter = 3
+	d.ParallelPage = 3
+	memory.Images.Default = backendtest.Behavior{Delay: 20 * time.Millisecond}
+	list := chapters(t, memory, manga)
+
+	results := make(chan error)
+	d.Download(manga, list, output, results)
+	for err := range results {
+		if err != nil {
+			t.Error(err)
+		}
+	}
+
+	if inFlight := memory.Images.MaxInFlight(); inFlight != 2 {
+		t.Errorf("served %d images at once, want 2", inFlight)
+	}
+	for _, chapter := range list {
+		if !d.Downloaded(manga, chapter, output) {
+			t.Errorf("%s not downloaded", chapter.Name)
+		}
+	}
+}
+
+func TestDownloadPageAsksMangaDexForAnotherServer(t *testing.T) {
+	images := backendtest.NewImageServer()
+	t.Cleanup(images.Close)
+	server := backendtest.NewMangaDexServer(images)
+	t.Cleanup(server.Close)
+	server.AddManga("Berserk", "Miura Kentarou", 1, 2, "en")
+
+	b := &backends.MangaDex{Client: server.Client(), Languages: []string{"en"}}
+	results, err := b.Search(backends.NewQuery("berserk"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	manga := results[0]
+	chapter := chapters(t, b, manga)[0]
+	images.SetBehavior("/data/manga-1-en-1/2.png", backendtest.Behavior{Failures: 1, FailureStatus: http.StatusForbidden})
+
+	output, err := ioutil.TempDir("", "katago-downloads-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(output) })
+
+	err = downloader.New(b, testClient()).DownloadChapter(manga, chapter, output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Once for the pages, once more after the forbidden image
+	if requests := server.Requests("at-home"); requests != 2 {
+		t.Errorf("at-home server asked %d times, want 2", requests)
+	}
+}
@@ -0,0 +1,23 @@
+package downloader
+
+import (
+	"fmt"
+
+	"github.com/toxinu/katago/backends"
+)
+
+// PageError is returned when a chapter page cannot be downloaded
+type PageError struct {
+	Chapter *backends.Chapter
+	Index   int
+	Err     error
+}
+
+func (e *PageError) Error() string {
+	return fmt.Sprintf("%s, page %d: %s", e.Chapter.Name, e.Index, e.Err)
+}
+
+// Unwrap returns underlying error
+func (e *PageError) Unwrap() error {
+	return e.Err
+}
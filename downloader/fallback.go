@@ -0,0 +1,167 @@
+package downloader
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path"
+
+	"github.com/toxinu/katago/backends"
+	"github.com/toxinu/katago/client"
+	"github.com/toxinu/katago/config"
+)
+
+// SourceFile is the name of the file recording where a chapter comes from,
+// written in every downloaded chapter folder
+const SourceFile = "source.json"
+
+// ChapterSource records the backend a chapter was downloaded from
+type ChapterSource struct {
+	Backend    string `json:"backend"`
+	MangaURL   string `json:"manga_url"`
+	ChapterURL string `json:"chapter_url"`
+	// FallbackFrom is the backend the chapter failed on, when it was
+	// downloaded from a fallback
+	FallbackFrom string `json:"fallback_from,omitempty"`
+	// Error is why the chapter failed on FallbackFrom
+	Error string `json:"error,omitempty"`
+}
+
+// Fallback is a backend chapters failing on the Downloader backend are
+// looked up on, by chapter number
+type Fallback struct {
+	Slug    string
+	Backend backends.Backend
+	Client  *client.Client
+}
+
+// mirror is a series found on a fallback backend, nil manga when missing
+type mirror struct {
+	manga    *backends.Manga
+	chapters []*backends.Chapter
+	err      error
+}
+
+// fallbacks returns configured fallbacks of given backend, none when not
+// configured
+func fallbacks(cfg *config.Config, backendName string) []*Fallback {
+	result := make([]*Fallback, 0, len(cfg.Fallbacks))
+	for _, slug := range cfg.Fallbacks {
+		b, ok := backends.Backends[slug]
+		if !ok || slug == backendName {
+			continue
+		}
+		result = append(result, &Fallback{Slug: slug, Backend: b, Client: backends.Clients[slug]})
+	}
+	return result
+}
+
+// backendFailure tells whether a chapter failed because of its backend or
+// site, rather than being cancelled or failing to write to the disk, which
+// another backend would not fix
+func backendFailure(err error) bool {
+	var (
+		pathError *os.PathError
+		linkError *os.LinkError
+	)
+	return !errors.Is(err, client.ErrCancelled) && !errors.As(err, &pathError) && !errors.As(err, &linkError)
+}
+
+// fallback downloads a chapter which failed with given error from the first
+// fallback carrying a chapter of the same number, given error is returned
+// when none does
+func (d *Downloader) fallback(manga *backends.Manga, chapter *backends.Chapter, output string, cause error) error {
+	if _, ok := backends.ChapterNumber(chapter); !ok {
+		return cause
+	}
+
+	chapterOutput := ChapterDir(output, manga, chapter)
+	for _, f := range d.Fallbacks {
+		m := d.mirror(f, manga)
+		if m.err != nil || m.manga == nil {
+			continue
+		}
+
+		alternative := backends.FindChapter(m.chapters, chapter)
+		if alternative == nil {
+			continue
+		}
+
+		// Pages go to a temporary folder replacing the failed attempt once
+		// complete
+		temporary := &backends.Chapter{Name: chapter.Name + ".fallback", URL: alternative.URL}
+		temporaryOutput := ChapterDir(output, manga, temporary)
+
+		// The fallback is cancelled along with the Downloader
+		fd := New(f.Backend, f.Client.WithContext(d.Client.Context()))
+		fd.Slug = f.Slug
+		fd.ParallelPage = d.ParallelPage
+		err := fd.downloadChapter(manga, temporary, output, &ChapterSource{
+			Backend:      f.Slug,
+			MangaURL:     m.manga.URL.String(),
+			ChapterURL:   alternative.URL.String(),
+			FallbackFrom: d.slug(),
+			Error:        cause.Error(),
+		})
+		if err == nil {
+			err = os.RemoveAll(chapterOutput)
+			if err != nil {
+				return err
+			}
+			return os.Rename(temporaryOutput, chapterOutput)
+		}
+
+		os.RemoveAll(temporaryOutput)
+		if errors.Is(err, client.ErrCancelled) {
+			return err
+		}
+	}
+
+	return cause
+}
+
+// mirror returns given manga as found on a fallback, looked up once per
+// Downloader, chapters failing together may both look it up
+func (d *Downloader) mirror(f *Fallback, manga *backends.Manga) *mirror {
+	key := f.Slug + " " + manga.URL.String()
+
+	d.mutex.Lock()
+	m, ok := d.mirrors[key]
+	d.mutex.Unlock()
+	if ok {
+		return m
+	}
+
+	m = &mirror{}
+	results, err := backends.Search(f.Backend, backends.NewQuery(manga.Name))
+	if err != nil {
+		m.err = err
+	}
+	m.manga = backends.FindSeries(manga, results)
+	if m.manga != nil {
+		m.chapters, m.err = f.Backend.Chapters(m.manga)
+	}
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if d.mirrors == nil {
+		d.mirrors = map[string]*mirror{}
+	}
+	d.mirrors[key] = m
+	return m
+}
+
+func writeChapterSource(output string, source *ChapterSource) error {
+	data, err := json.MarshalIndent(source, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(output, 0755)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path.Join(output, SourceFile), data, 0644)
+}
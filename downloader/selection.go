@@ -0,0 +1,252 @@
+package downloader
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/toxinu/katago/backends"
+)
+
+// selectorKind is what a selection term matches chapters on
+type selectorKind int
+
+const (
+	selectAll selectorKind = iota
+	selectNew
+	selectLatest
+	selectIndex
+	selectNumber
+	selectVolume
+)
+
+// selector is a single selection term, from and to bound indexes, chapter
+// numbers or volumes, both included
+type selector struct {
+	term    string
+	kind    selectorKind
+	from    float64
+	to      float64
+	single  bool
+	exclude bool
+}
+
+// Selection is a parsed chapter selection, terms are separated by commas or
+// spaces:
+//
+//   - "3", "3-7", "50-", "-10": chapter list indexes, starting at 0
+//   - "c12", "c10.5-20", "c50-", "c-10": chapter numbers
+//   - "v3", "v1-2": volumes
+//   - "latest", "latest:5": last chapters of the list
+//   - "all": every chapter
+//   - "new": chapters not downloaded yet
+//   - "!term": excludes chapters the term matches
+//
+// Chapters any term matches are selected, minus the excluded ones, every
+// chapter is selected when all terms are exclusions
+type Selection struct {
+	selectors []*selector
+}
+
+// ParseSelection parses given chapter selection expression
+func ParseSelection(expression string) (*Selection, error) {
+	terms := strings.FieldsFunc(expression, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t'
+	})
+	if len(terms) == 0 {
+		return nil, fmt.Errorf("empty chapter selection")
+	}
+
+	selection := &Selection{}
+	for _, term := range terms {
+		s, err := parseSelector(term)
+		if err != nil {
+			return nil, err
+		}
+		selection.selectors = append(selection.selectors, s)
+	}
+	return selection, nil
+}
+
+func parseSelector(term string) (*selector, error) {
+	s := &selector{term: term}
+	value := strings.ToLower(term)
+	if strings.HasPrefix(value, "!") {
+		s.exclude = true
+		value = value[1:]
+	}
+
+	switch {
+	case value == "all":
+		s.kind = selectAll
+		return s, nil
+	case value == "new":
+		s.kind = selectNew
+		return s, nil
+	case value == "latest":
+		s.kind, s.from = selectLatest, 1
+		return s, nil
+	case strings.HasPrefix(value, "latest:"):
+		count, err := strconv.Atoi(strings.TrimPrefix(value, "latest:"))
+		if err != nil || count < 1 {
+			return nil, fmt.Errorf("invalid latest chapters count: \"%s\"", term)
+		}
+		s.kind, s.from = selectLatest, float64(count)
+		return s, nil
+	case strings.HasPrefix(value, "c"):
+		s.kind = selectNumber
+		value = value[1:]
+	case strings.HasPrefix(value, "v"):
+		s.kind = selectVolume
+		value = value[1:]
+	default:
+		s.kind = selectIndex
+	}
+
+	err := s.parseRange(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid chapter selection: \"%s\"", term)
+	}
+	return s, nil
+}
+
+// parseRange parses "n", "n-m", "n-" and "-m", indexes must be integers
+func (s *selector) parseRange(value string) error {
+	parse := func(bound string, open float64) (float64, error) {
+		if len(bound) == 0 {
+			return open, nil
+		}
+		if s.kind == selectIndex {
+			n, err := strconv.Atoi(bound)
+			return float64(n), err
+		}
+		return strconv.ParseFloat(bound, 64)
+	}
+
+	bounds := strings.Split(value, "-")
+	if len(bounds) > 2 || len(value) == 0 || value == "-" {
+		return fmt.Errorf("invalid range")
+	}
+
+	var err error
+	s.from, err = parse(bounds[0], 0)
+	if err != nil {
+		return err
+	}
+	if len(bounds) == 1 {
+		s.to, s.single = s.from, true
+		return nil
+	}
+
+	s.to, err = parse(bounds[1], math.Inf(1))
+	if err != nil {
+		return err
+	}
+	if s.from > s.to {
+		return fmt.Errorf("invalid range")
+	}
+	return nil
+}
+
+// match tells whether the chapter at given index of chapters matches
+func (s *selector) match(chapters []*backends.Chapter, index int, downloaded func(*backends.Chapter) bool) bool {
+	chapter := chapters[index]
+	switch s.kind {
+	case selectAll:
+		return true
+	case selectNew:
+		return downloaded == nil || !downloaded(chapter)
+	case selectLatest:
+		return index >= len(chapters)-int(s.from)
+	case selectIndex:
+		return s.within(float64(index), true)
+	case selectNumber:
+		number, ok := backends.ChapterNumber(chapter)
+		return s.within(number, ok)
+	case selectVolume:
+		volume, ok := backends.ChapterVolume(chapter)
+		return s.within(volume, ok)
+	default:
+		return false
+	}
+}
+
+func (s *selector) within(value float64, ok bool) bool {
+	return ok && value >= s.from && value <= s.to
+}
+
+// Select returns the selected chapters in list order, downloaded tells
+// whether a chapter was already downloaded for the "new" term. Single
+// indexes, chapter numbers and volumes matching no chapter are errors.
+func (s *Selection) Select(chapters []*backends.Chapter, downloaded func(*backends.Chapter) bool) ([]*backends.Chapter, error) {
+	included := map[int]bool{}
+	excluded := map[int]bool{}
+	onlyExclusions := true
+
+	for _, selector := range s.selectors {
+		if !selector.exclude {
+			onlyExclusions = false
+		}
+
+		found := false
+		for i := range chapters {
+			if !selector.match(chapters, i, downloaded) {
+				continue
+			}
+			found = true
+			if selector.exclude {
+				excluded[i] = true
+			} else {
+				included[i] = true
+			}
+		}
+
+		if !found && selector.single && !selector.exclude {
+			return nil, fmt.Errorf("chapter \"%s\" is not available", selector.term)
+		}
+	}
+
+	selected := make([]*backends.Chapter, 0, len(included))
+	for i, chapter := range chapters {
+		if (onlyExclusions || included[i]) && !excluded[i] {
+			selected = append(selected, chapter)
+		}
+	}
+	return selected, nil
+}
+
+// Downloaded tells whether given chapter was downloaded to given output,
+// chapter folders record their source once complete. Folders downloaded
+// before sources were recorded are complete when they hold every page of the
+// chapter.
+func (d *Downloader) Downloaded(manga *backends.Manga, chapter *backends.Chapter, output string) bool {
+	chapterOutput := ChapterDir(output, manga, chapter)
+	_, err := os.Stat(path.Join(chapterOutput, SourceFile))
+	if err == nil {
+		return true
+	}
+
+	files, err := ioutil.ReadDir(chapterOutput)
+	if err != nil || len(files) == 0 {
+		return false
+	}
+
+	pages, err := d.Backend.Pages(chapter)
+	if err != nil || len(pages) == 0 {
+		return false
+	}
+
+	found := make(map[int]bool, len(pages))
+	for _, file := range files {
+		index, err := strconv.Atoi(strings.TrimSuffix(file.Name(), filepath.Ext(file.Name())))
+		if err == nil && !file.IsDir() && index >= 1 && index <= len(pages) {
+			found[index] = true
+		}
+	}
+	return len(found) == len(pages)
+}
@@ -0,0 +1,116 @@
+package downloader_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/toxinu/katago/backends"
+	"github.com/toxinu/katago/downloader"
+)
+
+func TestSelect(t *testing.T) {
+	manga := &backends.Manga{Name: "Berserk"}
+	chapters := []*backends.Chapter{
+		{Name: "Vol. 1 Ch. 1"},
+		{Name: "Vol. 1 Ch. 2"},
+		{Name: "Vol. 2 Ch. 2.5"},
+		{Name: "Vol. 2 Ch. 3"},
+		{Name: "Vol. 3 Ch. 4"},
+	}
+
+	output, err := ioutil.TempDir("", "katago-selection-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(output)
+
+	// Chapter 1 is complete, chapter 2 was downloaded before sources were
+	// recorded, chapter 3 only has an empty folder and chapter 4 was
+	// interrupted
+	files := map[int][]string{0: {downloader.SourceFile}, 1: {"1.jpg", "2.jpg"}, 2: nil, 3: {"1.jpg"}}
+	for index, names := range files {
+		chapterOutput := downloader.ChapterDir(output, manga, chapters[index])
+		if err := os.MkdirAll(chapterOutput, 0755); err != nil {
+			t.Fatal(err)
+		}
+		for _, name := range names {
+			if err := ioutil.WriteFile(filepath.Join(chapterOutput, name), nil, 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	d := downloader.New(twoPages{}, nil)
+	downloaded := func(chapter *backends.Chapter) bool {
+		return d.Downloaded(manga, chapter, output)
+	}
+
+	tests := []struct {
+		expression string
+		want       []int
+		err        bool
+	}{
+		{"all", []int{0, 1, 2, 3, 4}, false},
+		{"1", []int{1}, false},
+		{"1-2,4", []int{1, 2, 4}, false},
+		{"3-", []int{3, 4}, false},
+		{"c2", []int{1}, false},
+		{"c2-3", []int{1, 2, 3}, false},
+		{"v2", []int{2, 3}, false},
+		{"v2-3 !c3", []int{2, 4}, false},
+		{"latest", []int{4}, false},
+		{"latest:2", []int{3, 4}, false},
+		{"new", []int{2, 3, 4}, false},
+		{"!0", []int{1, 2, 3, 4}, false},
+		{"9", nil, true},
+		{"c12", nil, true},
+		{"2-1", nil, true},
+		{"latest:0", nil, true},
+		{"", nil, true},
+	}
+
+	for _, test := range tests {
+		selection, err := downloader.ParseSelection(test.expression)
+		var selected []*backends.Chapter
+		if err == nil {
+			selected, err = selection.Select(chapters, downloaded)
+		}
+		if test.err {
+			if err == nil {
+				t.Errorf("%q: no error", test.expression)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: %s", test.expression, err)
+			continue
+		}
+
+		want := make([]*backends.Chapter, 0, len(test.want))
+		for _, index := range test.want {
+			want = append(want, chapters[index])
+		}
+		if !reflect.DeepEqual(selected, want) {
+			t.Errorf("%q: got %v, want chapters %v", test.expression, names(selected), test.want)
+		}
+	}
+}
+
+// twoPages is a backend whose chapters all have two pages
+type twoPages struct {
+	backends.Backend
+}
+
+func (twoPages) Pages(chapter *backends.Chapter) ([]*backends.Page, error) {
+	return []*backends.Page{{}, {}}, nil
+}
+
+func names(chapters []*backends.Chapter) []string {
+	result := make([]string, 0, len(chapters))
+	for _, chapter := range chapters {
+		result = append(result, chapter.Name)
+	}
+	return result
+}